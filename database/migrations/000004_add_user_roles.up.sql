@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'cashier'
+    CHECK (role IN ('owner', 'manager', 'cashier'));
+
+-- Accounts created before roles existed had full access; keep it that way.
+UPDATE users SET role = 'owner';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS sales_returns (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    reason TEXT NOT NULL,
+    refund_amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS sales_return_items (
+    id SERIAL PRIMARY KEY,
+    return_id INTEGER NOT NULL REFERENCES sales_returns(id) ON DELETE CASCADE,
+    transaction_detail_id INTEGER NOT NULL REFERENCES transaction_details(id),
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    refund_amount BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_returns_transaction_id ON sales_returns(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_sales_return_items_detail_id ON sales_return_items(transaction_detail_id);
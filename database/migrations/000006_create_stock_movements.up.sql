@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    type VARCHAR(20) NOT NULL
+        CHECK (type IN ('sale', 'return', 'purchase', 'adjustment', 'transfer', 'cancellation')),
+    quantity INTEGER NOT NULL CHECK (quantity <> 0),
+    stock_after INTEGER NOT NULL,
+    reference_type VARCHAR(50),
+    reference_id INTEGER,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, id);
+
+-- Open the ledger with the current stock so that the sum of movements per
+-- product equals products.stock from here on.
+INSERT INTO stock_movements (product_id, type, quantity, stock_after, note)
+SELECT id, 'adjustment', stock, stock, 'Opening balance'
+FROM products
+WHERE stock <> 0;
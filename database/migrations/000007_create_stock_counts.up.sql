@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS stock_counts (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'approved', 'cancelled')),
+    category_id INTEGER REFERENCES categories(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ
+);
+
+-- Only one count session may be open at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_counts_single_open ON stock_counts ((true)) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS stock_count_items (
+    stock_count_id INTEGER NOT NULL REFERENCES stock_counts(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    counted_quantity INTEGER NOT NULL CHECK (counted_quantity >= 0),
+    system_stock INTEGER NOT NULL,
+    counted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (stock_count_id, product_id)
+);
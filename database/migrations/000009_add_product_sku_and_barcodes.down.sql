@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_barcodes;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
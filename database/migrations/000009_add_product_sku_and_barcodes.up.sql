@@ -0,0 +1,8 @@
+ALTER TABLE products ADD COLUMN sku VARCHAR(64) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    code VARCHAR(32) PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes(product_id);
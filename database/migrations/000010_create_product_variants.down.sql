@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_stock_count_items_line;
+DELETE FROM stock_count_items WHERE variant_id IS NOT NULL;
+ALTER TABLE stock_count_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_count_items ADD PRIMARY KEY (stock_count_id, product_id);
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE purchase_order_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
@@ -0,0 +1,33 @@
+-- Option types of a product, e.g. Size and Color, in display order.
+CREATE TABLE IF NOT EXISTS product_options (
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (product_id, name)
+);
+
+-- A sellable combination of option values with its own SKU, price and
+-- stock. products.stock of a product with variants is the sum of its
+-- variants' stock.
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    sku VARCHAR(64) UNIQUE,
+    price BIGINT NOT NULL CHECK (price > 0),
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    options JSONB NOT NULL DEFAULT '{}',
+    UNIQUE (product_id, options)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants(id);
+ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants(id);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants(id) ON DELETE SET NULL;
+
+-- Products with variants are counted per variant.
+ALTER TABLE stock_count_items ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants(id);
+ALTER TABLE stock_count_items DROP CONSTRAINT IF EXISTS stock_count_items_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_count_items_line
+    ON stock_count_items (stock_count_id, product_id, COALESCE(variant_id, 0));
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS transaction_discounts;
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS discount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS discount_total;
+ALTER TABLE transactions DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('percentage', 'fixed_amount', 'buy_x_get_y')),
+    percent INTEGER NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount BIGINT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    buy_quantity INTEGER NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    free_quantity INTEGER NOT NULL DEFAULT 0 CHECK (free_quantity >= 0),
+    target_type TEXT NOT NULL DEFAULT 'all' CHECK (target_type IN ('all', 'product', 'category')),
+    target_id INTEGER,
+    code TEXT UNIQUE,
+    stackable BOOLEAN NOT NULL DEFAULT false,
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((target_type = 'all') = (target_id IS NULL)),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount_total BIGINT NOT NULL DEFAULT 0;
+UPDATE transactions SET subtotal = total_amount;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_discounts (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INTEGER NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL,
+    promotion_name TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_discounts_detail_id ON transaction_discounts(transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_discounts_promotion_id ON transaction_discounts(promotion_id);
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS transaction_taxes;
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS total;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_inclusive;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_name;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_rate_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tax_total;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_rate_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_rate_id;
+
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_payments;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS change_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS payment_status;
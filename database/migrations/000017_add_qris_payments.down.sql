@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_transaction_payments_qris_reference;
+
+DELETE FROM transaction_payments WHERE method = 'qris';
+
+ALTER TABLE transaction_payments DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE transaction_payments DROP COLUMN IF EXISTS qr_payload;
+ALTER TABLE transaction_payments DROP COLUMN IF EXISTS status;
+
+ALTER TABLE transaction_payments DROP CONSTRAINT IF EXISTS transaction_payments_method_check;
+ALTER TABLE transaction_payments ADD CONSTRAINT transaction_payments_method_check
+    CHECK (method IN ('cash', 'debit_card', 'credit_card', 'e_wallet'));
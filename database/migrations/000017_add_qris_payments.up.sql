@@ -0,0 +1,11 @@
+ALTER TABLE transaction_payments DROP CONSTRAINT IF EXISTS transaction_payments_method_check;
+ALTER TABLE transaction_payments ADD CONSTRAINT transaction_payments_method_check
+    CHECK (method IN ('cash', 'debit_card', 'credit_card', 'e_wallet', 'qris'));
+
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'paid'
+    CHECK (status IN ('pending', 'paid', 'failed', 'expired'));
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS qr_payload TEXT NOT NULL DEFAULT '';
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_payments_qris_reference
+    ON transaction_payments(reference) WHERE method = 'qris';
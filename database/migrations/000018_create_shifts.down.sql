@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_sales_returns_shift_id;
+ALTER TABLE sales_returns DROP COLUMN IF EXISTS shift_id;
+ALTER TABLE sales_returns DROP COLUMN IF EXISTS refund_method;
+ALTER TABLE transaction_payments DROP COLUMN IF EXISTS shift_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS shift_id;
+
+DROP TABLE IF EXISTS shift_cash_movements;
+DROP TABLE IF EXISTS shifts;
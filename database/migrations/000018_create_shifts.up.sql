@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    opening_cash BIGINT NOT NULL CHECK (opening_cash >= 0),
+    cash_sales BIGINT NOT NULL DEFAULT 0,
+    cash_refunds BIGINT NOT NULL DEFAULT 0,
+    cash_in BIGINT NOT NULL DEFAULT 0,
+    cash_out BIGINT NOT NULL DEFAULT 0,
+    expected_cash BIGINT NOT NULL DEFAULT 0,
+    counted_cash BIGINT,
+    variance BIGINT,
+    note TEXT NOT NULL DEFAULT '',
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ
+);
+
+-- A user has at most one open shift.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_user ON shifts(user_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_shifts_opened_at ON shifts(opened_at);
+
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INTEGER NOT NULL REFERENCES shifts(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('cash_in', 'cash_out')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_cash_movements_shift_id ON shift_cash_movements(shift_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions(shift_id);
+
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts(id);
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_shift_id ON transaction_payments(shift_id);
+
+-- Returns record how they were refunded; cash refunds come out of the
+-- drawer of the shift that recorded them.
+ALTER TABLE sales_returns ADD COLUMN IF NOT EXISTS refund_method TEXT NOT NULL DEFAULT 'cash'
+    CHECK (refund_method IN ('cash', 'debit_card', 'credit_card', 'e_wallet'));
+ALTER TABLE sales_returns ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts(id);
+CREATE INDEX IF NOT EXISTS idx_sales_returns_shift_id ON sales_returns(shift_id);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_transaction_details_product_id ON transaction_details(product_id);
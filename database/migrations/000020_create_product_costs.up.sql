@@ -0,0 +1,23 @@
+-- Cost price history of products. Goods receipts record the cost of each
+-- batch received; manual entries set a cost, optionally for a quantity such
+-- as the stock on hand when costs started being tracked. Products with
+-- variants are costed per variant; the costs of a variant that was never
+-- sold or ordered go with it when it is deleted.
+CREATE TABLE IF NOT EXISTS product_costs (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    variant_id INTEGER REFERENCES product_variants(id) ON DELETE CASCADE,
+    unit_cost BIGINT NOT NULL CHECK (unit_cost >= 0),
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('purchase', 'manual')),
+    purchase_order_id INTEGER REFERENCES purchase_orders(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_costs_product_id ON product_costs(product_id, variant_id, created_at);
+
+INSERT INTO product_costs (product_id, variant_id, unit_cost, quantity, source, purchase_order_id, created_at)
+SELECT i.product_id, i.variant_id, r.unit_cost, r.quantity, 'purchase', i.purchase_order_id, r.received_at
+FROM purchase_order_receipts r
+JOIN purchase_order_items i ON r.purchase_order_item_id = i.id;
@@ -0,0 +1,3 @@
+ALTER TABLE products DROP COLUMN IF EXISTS low_stock_alerted;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_qty;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_point;
@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_point INTEGER NOT NULL DEFAULT 0 CHECK (reorder_point >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_qty INTEGER NOT NULL DEFAULT 0 CHECK (reorder_qty >= 0);
+
+-- Set once a low-stock alert went out for the product and cleared when its
+-- stock rises above the reorder point again, so each dip alerts once.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS low_stock_alerted BOOLEAN NOT NULL DEFAULT false;
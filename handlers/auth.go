@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type AuthHandler struct {
+	service services.AuthService
+}
+
+func NewAuthHandler(service services.AuthService) *AuthHandler {
+	return &AuthHandler{service}
+}
+
+// Login godoc
+// @Summary      Log in
+// @Description  Exchange username and password for an access and refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        credentials  body      models.LoginRequest  true  "Credentials"
+// @Success      200          {object}  utils.APIResponse{data=models.AuthToken}
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      401          {object}  utils.APIResponse
+// @Failure      500          {object}  utils.APIResponse
+// @Router       /api/auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Username and password are required")
+		return
+	}
+
+	token, err := h.service.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			utils.ResponseError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(w, "Login successful", token)
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchange a refresh token for a new access and refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      models.RefreshRequest  true  "Refresh token"
+// @Success      200    {object}  utils.APIResponse{data=models.AuthToken}
+// @Failure      400    {object}  utils.APIResponse
+// @Failure      401    {object}  utils.APIResponse
+// @Failure      500    {object}  utils.APIResponse
+// @Router       /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.RefreshToken == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	token, err := h.service.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			utils.ResponseError(w, http.StatusUnauthorized, models.ErrInvalidToken.Error())
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(w, "Token refreshed successfully", token)
+}
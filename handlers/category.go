@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -24,16 +25,25 @@ func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        page      query     int  false  "Page number" default(1)
-// @Param        page_size query     int  false  "Page size" default(10)
-// @Success      200       {object}  utils.APIResponse{data=[]models.Category}
-// @Failure      500       {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Param        page             query     int   false  "Page number" default(1)
+// @Param        page_size        query     int   false  "Page size" default(10)
+// @Param        include_deleted  query     bool  false  "Include deleted categories (owner and manager only)"
+// @Success      200              {object}  utils.APIResponse{data=[]models.Category}
+// @Failure      400              {object}  utils.APIResponse
+// @Failure      403              {object}  utils.APIResponse
+// @Failure      500              {object}  utils.APIResponse
 // @Router       /api/categories [get]
 func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
 
-	categories, meta, err := h.service.GetAllCategories(page, pageSize)
+	includeDeleted, ok := parseIncludeDeleted(w, r, models.PermCategoryWrite)
+	if !ok {
+		return
+	}
+
+	categories, meta, err := h.service.GetAllCategories(page, pageSize, includeDeleted)
 	if err != nil {
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -47,6 +57,7 @@ func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request)
 // @Tags         categories
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        category  body      models.Category  true  "Category"
 // @Success      201       {object}  utils.APIResponse{data=models.Category}
 // @Failure      400       {object}  utils.APIResponse
@@ -63,9 +74,17 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
 		return
 	}
+	if category.ParentID != nil && *category.ParentID <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid parent_id")
+		return
+	}
 
 	createdCategory, err := h.service.CreateCategory(category)
 	if err != nil {
+		if errors.Is(err, models.ErrParentCategoryNotFound) || errors.Is(err, models.ErrTaxRateNotFound) {
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -73,17 +92,39 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	utils.ResponseCreated(w, "Category created successfully", createdCategory)
 }
 
+// GetCategoryTree godoc
+// @Summary      Show the category tree
+// @Description  Get all categories nested under their parent categories
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.APIResponse{data=[]models.CategoryNode}
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/categories/tree [get]
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetCategoryTree()
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccess(w, "Category tree retrieved successfully", tree)
+}
+
 // GetCategory godoc
 // @Summary      Get a category
 // @Description  Get category by ID
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Category ID"
-// @Success      200  {object}  utils.APIResponse{data=models.Category}
-// @Failure      400  {object}  utils.APIResponse
-// @Failure      404  {object}  utils.APIResponse
-// @Failure      500  {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Param        id               path      int   true   "Category ID"
+// @Param        include_deleted  query     bool  false  "Also find a deleted category (owner and manager only)"
+// @Success      200              {object}  utils.APIResponse{data=models.Category}
+// @Failure      400              {object}  utils.APIResponse
+// @Failure      403              {object}  utils.APIResponse
+// @Failure      404              {object}  utils.APIResponse
+// @Failure      500              {object}  utils.APIResponse
 // @Router       /api/categories/{id} [get]
 func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	id, ok := utils.ParseIDFromRequest(r, w)
@@ -91,7 +132,12 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	category, err := h.service.GetCategoryByID(id)
+	includeDeleted, ok := parseIncludeDeleted(w, r, models.PermCategoryWrite)
+	if !ok {
+		return
+	}
+
+	category, err := h.service.GetCategoryByID(id, includeDeleted)
 	if err != nil {
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -106,15 +152,17 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 
 // UpdateCategory godoc
 // @Summary      Update a category
-// @Description  Update category by ID
+// @Description  Update category by ID. parent_id cannot be the category itself or one of its subcategories.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id        path      int              true  "Category ID"
 // @Param        category  body      models.Category  true  "Category"
 // @Success      200       {object}  utils.APIResponse{data=models.Category}
 // @Failure      400       {object}  utils.APIResponse
 // @Failure      404       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
 // @Failure      500       {object}  utils.APIResponse
 // @Router       /api/categories/{id} [put]
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
@@ -133,10 +181,21 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
 		return
 	}
+	if category.ParentID != nil && *category.ParentID <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid parent_id")
+		return
+	}
 
 	updatedCategory, err := h.service.UpdateCategory(id, category)
 	if err != nil {
-		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, models.ErrParentCategoryNotFound), errors.Is(err, models.ErrTaxRateNotFound):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrCategoryCycle):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 	if updatedCategory == nil {
@@ -149,26 +208,87 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 // DeleteCategory godoc
 // @Summary      Delete a category
-// @Description  Delete category by ID
+// @Description  Soft-delete category by ID. A category that still has products or subcategories is rejected with 409 and their counts, unless reassign_to names a category to move them to first.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
+// @Param        id           path      int  true   "Category ID"
+// @Param        reassign_to  query     int  false  "Category to move products and subcategories to"
+// @Success      200          {object}  utils.APIResponse
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      404          {object}  utils.APIResponse
+// @Failure      409          {object}  utils.APIResponse{data=models.CategoryInUseError}
+// @Failure      500          {object}  utils.APIResponse
+// @Router       /api/categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var reassignTo *int
+	if v := r.URL.Query().Get("reassign_to"); v != "" {
+		target, err := strconv.Atoi(v)
+		if err != nil || target <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid reassign_to")
+			return
+		}
+		reassignTo = &target
+	}
+
+	err := h.service.DeleteCategory(id, reassignTo)
+	if err != nil {
+		var inUse *models.CategoryInUseError
+		switch {
+		case errors.As(err, &inUse):
+			utils.ResponseErrorWithData(w, http.StatusConflict, err.Error(), inUse)
+		case errors.Is(err, models.ErrCategoryNotFound):
+			utils.ResponseError(w, http.StatusNotFound, "Category not found")
+		case errors.Is(err, models.ErrInvalidReassignTarget):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseSuccess(w, "Category deleted successfully", nil)
+}
+
+// RestoreCategory godoc
+// @Summary      Restore a deleted category
+// @Description  Undo the deletion of a category. Its parent category must not be deleted.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
 // @Param        id   path      int  true  "Category ID"
-// @Success      200  {object}  utils.APIResponse
+// @Success      200  {object}  utils.APIResponse{data=models.Category}
 // @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
 // @Failure      500  {object}  utils.APIResponse
-// @Router       /api/categories/{id} [delete]
-func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+// @Router       /api/categories/{id}/restore [post]
+func (h *CategoryHandler) RestoreCategory(w http.ResponseWriter, r *http.Request) {
 	id, ok := utils.ParseIDFromRequest(r, w)
 	if !ok {
 		return
 	}
 
-	err := h.service.DeleteCategory(id)
+	category, err := h.service.RestoreCategory(id)
 	if err != nil {
+		if errors.Is(err, models.ErrCategoryDeleted) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if category == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Category not found")
+		return
+	}
 
-	utils.ResponseSuccess(w, "Category deleted successfully", nil)
+	utils.ResponseSuccess(w, "Category restored successfully", category)
 }
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type LowStockHandler struct {
+	service services.LowStockService
+}
+
+func NewLowStockHandler(service services.LowStockService) *LowStockHandler {
+	return &LowStockHandler{service}
+}
+
+// ListLowStock godoc
+// @Summary      Show products low on stock
+// @Description  List products whose stock is at or below their reorder point, with the quantity to reorder, the least stocked relative to their reorder point first. Products without a reorder point are left out.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.APIResponse{data=[]models.LowStockProduct}
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/inventory/low-stock [get]
+func (h *LowStockHandler) ListLowStock(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.GetLowStockProducts()
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccess(w, "Low-stock products retrieved successfully", products)
+}
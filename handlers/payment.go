@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+// maxCallbackBytes bounds the size of a payment gateway callback body.
+const maxCallbackBytes = 64 << 10
+
+type PaymentHandler struct {
+	service services.PaymentService
+}
+
+func NewPaymentHandler(service services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service}
+}
+
+// respondPaymentError writes the response for an error taking payment,
+// reporting whether err was a payment error.
+func respondPaymentError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, models.ErrPaymentInsufficient), errors.Is(err, models.ErrNonCashOverpayment),
+		errors.Is(err, models.ErrPaymentMethodNotSupported), errors.Is(err, models.ErrPaymentAmountMismatch),
+		errors.Is(err, models.ErrInvalidCallback):
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrInvalidSignature):
+		utils.ResponseError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, models.ErrPaymentDeclined):
+		utils.ResponseError(w, http.StatusPaymentRequired, err.Error())
+	case errors.Is(err, models.ErrPaymentNotFound):
+		utils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrTransactionAlreadyPaid), errors.Is(err, models.ErrTransactionCancelled),
+		errors.Is(err, models.ErrPaymentPending):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// CreateQRISPayment godoc
+// @Summary      Pay a transaction with QRIS
+// @Description  Start a dynamic QRIS payment for the total of an unpaid transaction and return the QR payload to display. The payment stays pending until the gateway's callback confirms it; asking again before it expires returns the same payment.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Transaction ID"
+// @Success      201  {object}  utils.APIResponse{data=models.Payment}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/qris [post]
+func (h *PaymentHandler) CreateQRISPayment(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	payment, err := h.service.CreateQRISPayment(id)
+	if err != nil {
+		if !respondPaymentError(w, err) {
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if payment == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	utils.ResponseCreated(w, "QRIS payment created successfully", payment)
+}
+
+// QRISCallback godoc
+// @Summary      QRIS payment callback
+// @Description  Called by the QRIS gateway with the final status of a payment, signed in the X-Callback-Signature header. A paid payment marks its transaction paid. Repeated callbacks for a payment that is no longer pending change nothing, except that a payment paid after it expired still settles an unpaid transaction.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        X-Callback-Signature  header    string  true  "Gateway signature of the body"
+// @Success      200                   {object}  utils.APIResponse{data=models.Payment}
+// @Failure      400                   {object}  utils.APIResponse
+// @Failure      401                   {object}  utils.APIResponse
+// @Failure      404                   {object}  utils.APIResponse
+// @Failure      500                   {object}  utils.APIResponse
+// @Router       /api/payments/callback [post]
+func (h *PaymentHandler) QRISCallback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackBytes))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payment, err := h.service.HandleQRISCallback(body, r.Header.Get("X-Callback-Signature"))
+	if err != nil {
+		if !respondPaymentError(w, err) {
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseSuccess(w, "Callback processed successfully", payment)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
+	"kasir-api/middleware"
 	"kasir-api/models"
 	"kasir-api/services"
 	"kasir-api/utils"
@@ -25,20 +29,36 @@ func Health(w http.ResponseWriter, r *http.Request) {
 
 // ListProducts godoc
 // @Summary      Show all products
-// @Description  Get all products with pagination
+// @Description  Get all products with pagination, search, filters and sorting
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        page      query     int  false  "Page number" default(1)
-// @Param        page_size query     int  false  "Page size" default(10)
-// @Success      200       {object}  utils.APIResponse{data=[]models.Product}
-// @Failure      500       {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Param        page                   query     int     false  "Page number" default(1)
+// @Param        page_size              query     int     false  "Page size" default(10)
+// @Param        q                      query     string  false  "Case-insensitive name search"
+// @Param        category_id            query     int     false  "Category ID"
+// @Param        include_subcategories  query     bool    false  "Also match products in subcategories of category_id"
+// @Param        min_price              query     int     false  "Minimum price"
+// @Param        max_price              query     int     false  "Maximum price"
+// @Param        in_stock               query     bool    false  "Only products in stock (true) or out of stock (false)"
+// @Param        sort                   query     string  false  "Sort field, prefix with - for descending" Enums(name, -name, price, -price, stock, -stock)
+// @Param        include_deleted        query     bool    false  "Include deleted products (owner and manager only)"
+// @Success      200                    {object}  utils.APIResponse{data=[]models.Product}
+// @Failure      400                    {object}  utils.APIResponse
+// @Failure      403                    {object}  utils.APIResponse
+// @Failure      500                    {object}  utils.APIResponse
 // @Router       /api/products [get]
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
 
-	products, meta, err := h.service.GetAllProducts(page, pageSize)
+	filter, ok := parseProductFilter(w, r)
+	if !ok {
+		return
+	}
+
+	products, meta, err := h.service.GetAllProducts(filter, page, pageSize)
 	if err != nil {
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -46,15 +66,105 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	utils.ResponseSuccessWithMeta(w, "Products retrieved successfully", products, meta)
 }
 
+// parseIncludeDeleted reads the include_deleted query flag. Only users
+// whose role grants permission may see deleted records; others get a 403.
+func parseIncludeDeleted(w http.ResponseWriter, r *http.Request, permission models.Permission) (bool, bool) {
+	v := r.URL.Query().Get("include_deleted")
+	if v == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid include_deleted")
+		return false, false
+	}
+	if user, ok := middleware.UserFromContext(r.Context()); include && (!ok || !user.Role.Can(permission)) {
+		utils.ResponseError(w, http.StatusForbidden, "You don't have permission to see deleted records")
+		return false, false
+	}
+	return include, true
+}
+
+// parseProductFilter reads and validates the list filters from the query
+// string, writing a 400 response when one is invalid.
+func parseProductFilter(w http.ResponseWriter, r *http.Request) (models.ProductFilter, bool) {
+	query := r.URL.Query()
+	filter := models.ProductFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Sort:  query.Get("sort"),
+	}
+
+	if v := query.Get("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid category_id")
+			return filter, false
+		}
+		filter.CategoryID = id
+	}
+	if v := query.Get("include_subcategories"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid include_subcategories")
+			return filter, false
+		}
+		filter.IncludeSubcategories = include
+	}
+	if v := query.Get("min_price"); v != "" {
+		price, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || price < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid min_price")
+			return filter, false
+		}
+		amount := models.Money(price)
+		filter.MinPrice = &amount
+	}
+	if v := query.Get("max_price"); v != "" {
+		price, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || price < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid max_price")
+			return filter, false
+		}
+		amount := models.Money(price)
+		filter.MaxPrice = &amount
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		utils.ResponseError(w, http.StatusBadRequest, "min_price cannot be greater than max_price")
+		return filter, false
+	}
+	if v := query.Get("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid in_stock")
+			return filter, false
+		}
+		filter.InStock = &inStock
+	}
+	if filter.Sort != "" && !slices.Contains(models.ProductSortOptions, filter.Sort) {
+		utils.ResponseError(w, http.StatusBadRequest, "sort must be one of "+strings.Join(models.ProductSortOptions, ", "))
+		return filter, false
+	}
+
+	includeDeleted, ok := parseIncludeDeleted(w, r, models.PermProductWrite)
+	if !ok {
+		return filter, false
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	return filter, true
+}
+
 // CreateProduct godoc
 // @Summary      Create a new product
 // @Description  Create a new product with the input payload
 // @Tags         products
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        product  body      models.Product  true  "Product"
 // @Success      201      {object}  utils.APIResponse{data=models.Product}
 // @Failure      400      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
 // @Failure      500      {object}  utils.APIResponse
 // @Router       /api/products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -76,13 +186,28 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		utils.ResponseError(w, http.StatusBadRequest, "Stock cannot be negative")
 		return
 	}
+	if product.ReorderPoint < 0 || product.ReorderQty < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Reorder point and quantity cannot be negative")
+		return
+	}
 	if product.CategoryID <= 0 {
 		utils.ResponseError(w, http.StatusBadRequest, "Category ID is required")
 		return
 	}
+	if !validateProductCodes(w, &product) || !validateProductOptions(w, product.Options) {
+		return
+	}
 
 	createdProduct, err := h.service.CreateProduct(product)
 	if err != nil {
+		if errors.Is(err, models.ErrSKUTaken) || errors.Is(err, models.ErrBarcodeTaken) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrCategoryNotFound) || errors.Is(err, models.ErrTaxRateNotFound) {
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -92,15 +217,18 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 // GetProduct godoc
 // @Summary      Get a product
-// @Description  Get product by ID
+// @Description  Get product by ID, including its variants
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Product ID"
-// @Success      200  {object}  utils.APIResponse{data=models.Product}
-// @Failure      400  {object}  utils.APIResponse
-// @Failure      404  {object}  utils.APIResponse
-// @Failure      500  {object}  utils.APIResponse
+// @Security     BearerAuth
+// @Param        id               path      int   true   "Product ID"
+// @Param        include_deleted  query     bool  false  "Also find a deleted product (owner and manager only)"
+// @Success      200              {object}  utils.APIResponse{data=models.Product}
+// @Failure      400              {object}  utils.APIResponse
+// @Failure      403              {object}  utils.APIResponse
+// @Failure      404              {object}  utils.APIResponse
+// @Failure      500              {object}  utils.APIResponse
 // @Router       /api/products/{id} [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id, ok := utils.ParseIDFromRequest(r, w)
@@ -108,7 +236,45 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.service.GetProductByID(id)
+	includeDeleted, ok := parseIncludeDeleted(w, r, models.PermProductWrite)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProductByID(id, includeDeleted)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if product == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Product retrieved successfully", product)
+}
+
+// GetProductByBarcode godoc
+// @Summary      Look up a product by barcode
+// @Description  Find the product a scanned EAN-13, UPC-A or EAN-8 barcode belongs to, including its variants
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code  path      string  true  "Barcode"
+// @Success      200   {object}  utils.APIResponse{data=models.Product}
+// @Failure      400   {object}  utils.APIResponse
+// @Failure      404   {object}  utils.APIResponse
+// @Failure      500   {object}  utils.APIResponse
+// @Router       /api/products/by-barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if err := utils.ValidateBarcode(code); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, err := h.service.GetProductByBarcode(code)
 	if err != nil {
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -127,12 +293,14 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 // @Tags         products
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id       path      int             true  "Product ID"
 // @Param        product  body      models.Product  true  "Product"
 // @Success      200      {object}  utils.APIResponse{data=models.Product}
 // @Failure      400      {object}  utils.APIResponse
-// @Failure      404  {object}  utils.APIResponse
-// @Failure      500  {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Failure      500      {object}  utils.APIResponse
 // @Router       /api/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id, ok := utils.ParseIDFromRequest(r, w)
@@ -159,13 +327,30 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		utils.ResponseError(w, http.StatusBadRequest, "Stock cannot be negative")
 		return
 	}
+	if product.ReorderPoint < 0 || product.ReorderQty < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Reorder point and quantity cannot be negative")
+		return
+	}
 	if product.CategoryID <= 0 {
 		utils.ResponseError(w, http.StatusBadRequest, "Category ID is required")
 		return
 	}
+	if !validateProductCodes(w, &product) || !validateProductOptions(w, product.Options) {
+		return
+	}
 
 	updatedProduct, err := h.service.UpdateProduct(id, product)
 	if err != nil {
+		if errors.Is(err, models.ErrStockCountInProgress) || errors.Is(err, models.ErrSKUTaken) ||
+			errors.Is(err, models.ErrBarcodeTaken) || errors.Is(err, models.ErrProductHasVariants) ||
+			errors.Is(err, models.ErrVariantRequired) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrCategoryNotFound) || errors.Is(err, models.ErrTaxRateNotFound) {
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -179,13 +364,15 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 // DeleteProduct godoc
 // @Summary      Delete a product
-// @Description  Delete product by ID
+// @Description  Soft-delete product by ID. It is hidden from the catalogue and can't be sold, but its sales history is kept.
 // @Tags         products
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id   path      int  true  "Product ID"
 // @Success      200  {object}  utils.APIResponse
 // @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
 // @Failure      500  {object}  utils.APIResponse
 // @Router       /api/products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
@@ -196,9 +383,96 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 
 	err := h.service.DeleteProduct(id)
 	if err != nil {
+		if errors.Is(err, models.ErrProductNotFound) {
+			utils.ResponseError(w, http.StatusNotFound, "Product not found")
+			return
+		}
 		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.ResponseSuccess(w, "Product deleted successfully", nil)
 }
+
+// RestoreProduct godoc
+// @Summary      Restore a deleted product
+// @Description  Undo the deletion of a product. Its category must not be deleted.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Product}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.RestoreProduct(id)
+	if err != nil {
+		if errors.Is(err, models.ErrCategoryDeleted) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if product == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Product restored successfully", product)
+}
+
+// validateProductCodes normalizes the SKU and barcodes of product and
+// writes a 400 response when a barcode is invalid or repeated.
+func validateProductCodes(w http.ResponseWriter, product *models.Product) bool {
+	product.SKU = strings.TrimSpace(product.SKU)
+	if len(product.SKU) > 64 {
+		utils.ResponseError(w, http.StatusBadRequest, "SKU cannot be longer than 64 characters")
+		return false
+	}
+
+	seen := make(map[string]bool, len(product.Barcodes))
+	for i, code := range product.Barcodes {
+		code = strings.TrimSpace(code)
+		if err := utils.ValidateBarcode(code); err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid barcode "+code+": "+err.Error())
+			return false
+		}
+		if seen[code] {
+			utils.ResponseError(w, http.StatusBadRequest, "Duplicate barcode "+code)
+			return false
+		}
+		seen[code] = true
+		product.Barcodes[i] = code
+	}
+	return true
+}
+
+// validateProductOptions trims the option type names of a product and
+// writes a 400 response when one is empty, too long or repeated.
+func validateProductOptions(w http.ResponseWriter, options []string) bool {
+	seen := make(map[string]bool, len(options))
+	for i, name := range options {
+		name = strings.TrimSpace(name)
+		if name == "" || len(name) > 50 || strings.Contains(name, ",") {
+			utils.ResponseError(w, http.StatusBadRequest, "Option names must be 1 to 50 characters without commas")
+			return false
+		}
+		if seen[name] {
+			utils.ResponseError(w, http.StatusBadRequest, "Duplicate option "+name)
+			return false
+		}
+		seen[name] = true
+		options[i] = name
+	}
+	return true
+}
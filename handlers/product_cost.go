@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type ProductCostHandler struct {
+	service services.ProductCostService
+}
+
+func NewProductCostHandler(service services.ProductCostService) *ProductCostHandler {
+	return &ProductCostHandler{service}
+}
+
+// ListProductCosts godoc
+// @Summary      Show the cost price history of a product
+// @Description  Get the costs recorded for a product by goods receipts and manual entries, newest first, with pagination. The first one is the current cost; products with variants have one per variant.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int  true   "Product ID"
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.ProductCost}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/products/{id}/costs [get]
+func (h *ProductCostHandler) ListProductCosts(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	costs, meta, err := h.service.GetCostsByProductID(id, page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Product costs retrieved successfully", costs, meta)
+}
+
+// SetProductCost godoc
+// @Summary      Set the cost price of a product
+// @Description  Record a manually entered cost, which becomes the product's current cost. Products with variants need a variant_id and are costed per variant. A quantity, e.g. the stock on hand at that cost, weighs it in weighted average costing.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      int                        true  "Product ID"
+// @Param        cost  body      models.ProductCostRequest  true  "Unit cost"
+// @Success      201   {object}  utils.APIResponse{data=models.ProductCost}
+// @Failure      400   {object}  utils.APIResponse
+// @Failure      404   {object}  utils.APIResponse
+// @Failure      500   {object}  utils.APIResponse
+// @Router       /api/products/{id}/costs [post]
+func (h *ProductCostHandler) SetProductCost(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.ProductCostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.UnitCost < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Unit cost cannot be negative")
+		return
+	}
+	if req.Quantity < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Quantity cannot be negative")
+		return
+	}
+
+	cost, err := h.service.SetCost(id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrProductNotFound):
+			utils.ResponseError(w, http.StatusNotFound, "Product not found")
+		case errors.Is(err, models.ErrVariantNotFound):
+			utils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrVariantRequired), errors.Is(err, models.ErrVariantNotAllowed):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseCreated(w, "Product cost recorded successfully", cost)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type ProductVariantHandler struct {
+	service services.ProductVariantService
+}
+
+func NewProductVariantHandler(service services.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{service}
+}
+
+func respondProductVariantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrProductNotFound), errors.Is(err, models.ErrVariantNotFound):
+		utils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrVariantOptions):
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrSKUTaken), errors.Is(err, models.ErrVariantOptionsTaken),
+		errors.Is(err, models.ErrVariantInUse), errors.Is(err, models.ErrProductStockNotEmpty),
+		errors.Is(err, models.ErrStockCountInProgress), errors.Is(err, models.ErrNegativeStock):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func parseVariantID(r *http.Request, w http.ResponseWriter) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("variant_id"))
+	if err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid variant ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeProductVariant reads and validates a variant from the request body,
+// writing a 400 response when it is invalid.
+func decodeProductVariant(w http.ResponseWriter, r *http.Request) (models.ProductVariant, bool) {
+	var variant models.ProductVariant
+	if err := json.NewDecoder(r.Body).Decode(&variant); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return variant, false
+	}
+
+	variant.Name = strings.TrimSpace(variant.Name)
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	if len(variant.SKU) > 64 {
+		utils.ResponseError(w, http.StatusBadRequest, "SKU cannot be longer than 64 characters")
+		return variant, false
+	}
+	if variant.Price <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Price must be greater than 0")
+		return variant, false
+	}
+	if variant.Stock < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Stock cannot be negative")
+		return variant, false
+	}
+	if len(variant.Options) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Options are required")
+		return variant, false
+	}
+	for name, value := range variant.Options {
+		variant.Options[name] = strings.TrimSpace(value)
+	}
+	return variant, true
+}
+
+// ListVariants godoc
+// @Summary      Show the variants of a product
+// @Description  Get all variants of a product with their options, price and stock
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  utils.APIResponse{data=[]models.ProductVariant}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/products/{id}/variants [get]
+func (h *ProductVariantHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	productID, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	variants, err := h.service.GetVariants(productID)
+	if err != nil {
+		respondProductVariantError(w, err)
+		return
+	}
+	utils.ResponseSuccess(w, "Variants retrieved successfully", variants)
+}
+
+// CreateVariant godoc
+// @Summary      Add a product variant
+// @Description  Add a variant with one value per product option, e.g. {"Size": "Large"}. The name defaults to the option values.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                    true  "Product ID"
+// @Param        variant  body      models.ProductVariant  true  "Variant"
+// @Success      201      {object}  utils.APIResponse{data=models.ProductVariant}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Failure      500      {object}  utils.APIResponse
+// @Router       /api/products/{id}/variants [post]
+func (h *ProductVariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	variant, ok := decodeProductVariant(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.service.CreateVariant(productID, variant)
+	if err != nil {
+		respondProductVariantError(w, err)
+		return
+	}
+	utils.ResponseCreated(w, "Variant created successfully", created)
+}
+
+// UpdateVariant godoc
+// @Summary      Update a product variant
+// @Description  Update a variant's name, SKU, price, options and stock
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      int                    true  "Product ID"
+// @Param        variant_id  path      int                    true  "Variant ID"
+// @Param        variant     body      models.ProductVariant  true  "Variant"
+// @Success      200         {object}  utils.APIResponse{data=models.ProductVariant}
+// @Failure      400         {object}  utils.APIResponse
+// @Failure      404         {object}  utils.APIResponse
+// @Failure      409         {object}  utils.APIResponse
+// @Failure      500         {object}  utils.APIResponse
+// @Router       /api/products/{id}/variants/{variant_id} [put]
+func (h *ProductVariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+	id, ok := parseVariantID(r, w)
+	if !ok {
+		return
+	}
+
+	variant, ok := decodeProductVariant(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.UpdateVariant(productID, id, variant)
+	if err != nil {
+		respondProductVariantError(w, err)
+		return
+	}
+	if updated == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Variant not found")
+		return
+	}
+	utils.ResponseSuccess(w, "Variant updated successfully", updated)
+}
+
+// DeleteVariant godoc
+// @Summary      Delete a product variant
+// @Description  Delete a variant that has no sales, purchase or stock count history. Remaining stock is written off.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      int  true  "Product ID"
+// @Param        variant_id  path      int  true  "Variant ID"
+// @Success      200         {object}  utils.APIResponse
+// @Failure      400         {object}  utils.APIResponse
+// @Failure      404         {object}  utils.APIResponse
+// @Failure      409         {object}  utils.APIResponse
+// @Failure      500         {object}  utils.APIResponse
+// @Router       /api/products/{id}/variants/{variant_id} [delete]
+func (h *ProductVariantHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+	id, ok := parseVariantID(r, w)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteVariant(productID, id); err != nil {
+		respondProductVariantError(w, err)
+		return
+	}
+	utils.ResponseSuccess(w, "Variant deleted successfully", nil)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type PromotionHandler struct {
+	service services.PromotionService
+}
+
+func NewPromotionHandler(service services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service}
+}
+
+func respondPromotionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrPromotionNotFound):
+		utils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrPromotionTargetNotFound):
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrPromoCodeTaken):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// normalizePromoCode makes voucher codes case-insensitive.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// decodePromotion reads and validates a promotion from the request body,
+// writing a 400 response when it is invalid. Active defaults to true.
+func decodePromotion(w http.ResponseWriter, r *http.Request) (models.Promotion, bool) {
+	promotion := models.Promotion{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return promotion, false
+	}
+
+	promotion.Name = strings.TrimSpace(promotion.Name)
+	if promotion.Name == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
+		return promotion, false
+	}
+
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		if promotion.Percent < 1 || promotion.Percent > 100 {
+			utils.ResponseError(w, http.StatusBadRequest, "Percent must be between 1 and 100")
+			return promotion, false
+		}
+		promotion.Amount, promotion.BuyQuantity, promotion.FreeQuantity = 0, 0, 0
+	case models.PromotionFixedAmount:
+		if promotion.Amount <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Amount must be greater than 0")
+			return promotion, false
+		}
+		promotion.Percent, promotion.BuyQuantity, promotion.FreeQuantity = 0, 0, 0
+	case models.PromotionBuyXGetY:
+		if promotion.BuyQuantity <= 0 || promotion.FreeQuantity <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Buy quantity and free quantity must be greater than 0")
+			return promotion, false
+		}
+		promotion.Percent, promotion.Amount = 0, 0
+	default:
+		utils.ResponseError(w, http.StatusBadRequest, "Type must be one of percentage, fixed_amount, buy_x_get_y")
+		return promotion, false
+	}
+
+	if promotion.TargetType == "" {
+		promotion.TargetType = models.PromotionTargetAll
+	}
+	if !promotion.TargetType.Valid() {
+		utils.ResponseError(w, http.StatusBadRequest, "Target type must be one of all, product, category")
+		return promotion, false
+	}
+	if promotion.TargetType == models.PromotionTargetAll {
+		promotion.TargetID = nil
+	} else if promotion.TargetID == nil {
+		utils.ResponseError(w, http.StatusBadRequest, "Target ID is required")
+		return promotion, false
+	}
+
+	promotion.Code = normalizePromoCode(promotion.Code)
+	if len(promotion.Code) > 32 {
+		utils.ResponseError(w, http.StatusBadRequest, "Code cannot be longer than 32 characters")
+		return promotion, false
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		utils.ResponseError(w, http.StatusBadRequest, "Ends at must be after starts at")
+		return promotion, false
+	}
+	return promotion, true
+}
+
+// ListPromotions godoc
+// @Summary      Show all promotions
+// @Description  Get all promotions and vouchers, newest first
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.Promotion}
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/promotions [get]
+func (h *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	promotions, meta, err := h.service.GetAllPromotions(page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Promotions retrieved successfully", promotions, meta)
+}
+
+// CreatePromotion godoc
+// @Summary      Create a promotion
+// @Description  Create a percentage, fixed_amount or buy_x_get_y promotion targeting all products, a product or a category (including its subcategories). Promotions with a code are vouchers redeemed at checkout.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        promotion  body      models.Promotion  true  "Promotion"
+// @Success      201        {object}  utils.APIResponse{data=models.Promotion}
+// @Failure      400        {object}  utils.APIResponse
+// @Failure      409        {object}  utils.APIResponse
+// @Failure      500        {object}  utils.APIResponse
+// @Router       /api/promotions [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	promotion, ok := decodePromotion(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.service.CreatePromotion(promotion)
+	if err != nil {
+		respondPromotionError(w, err)
+		return
+	}
+	utils.ResponseCreated(w, "Promotion created successfully", created)
+}
+
+// GetPromotion godoc
+// @Summary      Get a promotion
+// @Description  Get promotion by ID
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Promotion ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Promotion}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.service.GetPromotionByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if promotion == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Promotion not found")
+		return
+	}
+	utils.ResponseSuccess(w, "Promotion retrieved successfully", promotion)
+}
+
+// UpdatePromotion godoc
+// @Summary      Update a promotion
+// @Description  Replace a promotion's rule, target, validity window and stacking settings
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      int               true  "Promotion ID"
+// @Param        promotion  body      models.Promotion  true  "Promotion"
+// @Success      200        {object}  utils.APIResponse{data=models.Promotion}
+// @Failure      400        {object}  utils.APIResponse
+// @Failure      404        {object}  utils.APIResponse
+// @Failure      409        {object}  utils.APIResponse
+// @Failure      500        {object}  utils.APIResponse
+// @Router       /api/promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	promotion, ok := decodePromotion(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.UpdatePromotion(id, promotion)
+	if err != nil {
+		respondPromotionError(w, err)
+		return
+	}
+	if updated == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Promotion not found")
+		return
+	}
+	utils.ResponseSuccess(w, "Promotion updated successfully", updated)
+}
+
+// DeletePromotion godoc
+// @Summary      Delete a promotion
+// @Description  Delete promotion by ID. Discounts it gave on past sales are kept.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Promotion ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePromotion(id); err != nil {
+		respondPromotionError(w, err)
+		return
+	}
+	utils.ResponseSuccess(w, "Promotion deleted successfully", nil)
+}
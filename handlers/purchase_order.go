@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type PurchaseOrderHandler struct {
+	service services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service}
+}
+
+func respondPurchaseOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrPurchaseOrderNotFound):
+		utils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrSupplierNotFound), errors.Is(err, models.ErrProductNotFound),
+		errors.Is(err, models.ErrPurchaseOrderItemNotFound), errors.Is(err, models.ErrVariantNotFound),
+		errors.Is(err, models.ErrVariantRequired), errors.Is(err, models.ErrVariantNotAllowed):
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrInvalidPurchaseOrderTransition), errors.Is(err, models.ErrReceiveExceedsOrdered):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func decodePurchaseOrderRequest(w http.ResponseWriter, r *http.Request) (models.PurchaseOrderRequest, bool) {
+	var req models.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+
+	if req.SupplierID <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Supplier ID is required")
+		return req, false
+	}
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Product ID is required")
+			return req, false
+		}
+		if item.Quantity <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+			return req, false
+		}
+		if item.UnitCost < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Unit cost cannot be negative")
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// ListPurchaseOrders godoc
+// @Summary      Show all purchase orders
+// @Description  Get purchase orders, newest first, optionally filtered by status
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status    query     string  false  "Status" Enums(draft, ordered, partially_received, received, cancelled)
+// @Param        page      query     int     false  "Page number" default(1)
+// @Param        page_size query     int     false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.PurchaseOrder}
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/purchase-orders [get]
+func (h *PurchaseOrderHandler) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	status := models.PurchaseOrderStatus(r.URL.Query().Get("status"))
+
+	orders, meta, err := h.service.GetAllPurchaseOrders(status, page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Purchase orders retrieved successfully", orders, meta)
+}
+
+// CreatePurchaseOrder godoc
+// @Summary      Create a purchase order
+// @Description  Create a draft purchase order for a supplier
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        purchase_order  body      models.PurchaseOrderRequest  true  "Purchase order"
+// @Success      201             {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400             {object}  utils.APIResponse
+// @Failure      500             {object}  utils.APIResponse
+// @Router       /api/purchase-orders [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePurchaseOrderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	po, err := h.service.CreatePurchaseOrder(req)
+	if err != nil {
+		respondPurchaseOrderError(w, err)
+		return
+	}
+
+	utils.ResponseCreated(w, "Purchase order created successfully", po)
+}
+
+// GetPurchaseOrder godoc
+// @Summary      Get a purchase order
+// @Description  Get purchase order with its lines by ID
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase order ID"
+// @Success      200  {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	po, err := h.service.GetPurchaseOrderByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if po == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Purchase order not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Purchase order retrieved successfully", po)
+}
+
+// UpdatePurchaseOrder godoc
+// @Summary      Update a purchase order
+// @Description  Replace the supplier, note and lines of a draft purchase order
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id              path      int                          true  "Purchase order ID"
+// @Param        purchase_order  body      models.PurchaseOrderRequest  true  "Purchase order"
+// @Success      200             {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400             {object}  utils.APIResponse
+// @Failure      404             {object}  utils.APIResponse
+// @Failure      409             {object}  utils.APIResponse
+// @Failure      500             {object}  utils.APIResponse
+// @Router       /api/purchase-orders/{id} [put]
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	req, ok := decodePurchaseOrderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	po, err := h.service.UpdatePurchaseOrder(id, req)
+	if err != nil {
+		respondPurchaseOrderError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Purchase order updated successfully", po)
+}
+
+// OrderPurchaseOrder godoc
+// @Summary      Place a purchase order
+// @Description  Move a draft purchase order to ordered
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase order ID"
+// @Success      200  {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/purchase-orders/{id}/order [post]
+func (h *PurchaseOrderHandler) OrderPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	po, err := h.service.OrderPurchaseOrder(id)
+	if err != nil {
+		respondPurchaseOrderError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Purchase order placed successfully", po)
+}
+
+// CancelPurchaseOrder godoc
+// @Summary      Cancel a purchase order
+// @Description  Cancel a draft or ordered purchase order that has not been received
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase order ID"
+// @Success      200  {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	po, err := h.service.CancelPurchaseOrder(id)
+	if err != nil {
+		respondPurchaseOrderError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Purchase order cancelled successfully", po)
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary      Receive goods
+// @Description  Receive some or all PO lines, adding stock and recording the unit cost in the product's cost history
+// @Tags         purchase-orders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                                 true  "Purchase order ID"
+// @Param        receipt  body      models.ReceivePurchaseOrderRequest  true  "Received lines"
+// @Success      200      {object}  utils.APIResponse{data=models.PurchaseOrder}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Failure      500      {object}  utils.APIResponse
+// @Router       /api/purchase-orders/{id}/receive [post]
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.ReceivePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.Items) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Items are required")
+		return
+	}
+	for _, item := range req.Items {
+		if item.PurchaseOrderItemID <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Purchase order item ID is required")
+			return
+		}
+		if item.Quantity <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+			return
+		}
+		if item.UnitCost != nil && *item.UnitCost < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Unit cost cannot be negative")
+			return
+		}
+	}
+
+	po, err := h.service.ReceivePurchaseOrder(id, req)
+	if err != nil {
+		respondPurchaseOrderError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Goods received successfully", po)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type ReceiptHandler struct {
+	service services.ReceiptService
+}
+
+func NewReceiptHandler(service services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service}
+}
+
+var receiptContentTypes = map[models.ReceiptFormat]string{
+	models.ReceiptText:   "text/plain; charset=utf-8",
+	models.ReceiptESCPOS: "application/octet-stream",
+	models.ReceiptPDF:    "application/pdf",
+}
+
+// GetReceipt godoc
+// @Summary      Get a receipt
+// @Description  Render the receipt of a transaction with the store header, lines, discounts, taxes, tenders and change. text is plain text, escpos is the raw ESC/POS byte stream to send to a thermal printer, and pdf is a single page PDF the width of the paper.
+// @Tags         transactions
+// @Produce      plain
+// @Produce      octet-stream
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        id      path      int     true   "Transaction ID"
+// @Param        format  query     string  false  "Receipt format"  Enums(text, escpos, pdf)  default(text)
+// @Param        width   query     int     false  "Paper width in mm, defaults to RECEIPT_PAPER_WIDTH"  Enums(58, 80)
+// @Success      200     {file}    file
+// @Failure      400     {object}  utils.APIResponse
+// @Failure      404     {object}  utils.APIResponse
+// @Failure      500     {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/receipt [get]
+func (h *ReceiptHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	format := models.ReceiptText
+	if v := r.URL.Query().Get("format"); v != "" {
+		format = models.ReceiptFormat(v)
+		if !format.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "Format must be one of text, escpos, pdf")
+			return
+		}
+	}
+
+	var width models.PaperWidth
+	if v := r.URL.Query().Get("width"); v != "" {
+		mm, err := strconv.Atoi(v)
+		width = models.PaperWidth(mm)
+		if err != nil || !width.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "Width must be 58 or 80")
+			return
+		}
+	}
+
+	receipt, err := h.service.RenderReceipt(id, format, width)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if receipt == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", receiptContentTypes[format])
+	if format != models.ReceiptText {
+		extension := map[models.ReceiptFormat]string{models.ReceiptESCPOS: "bin", models.ReceiptPDF: "pdf"}[format]
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%d.%s"`, id, extension))
+	}
+	w.Write(receipt)
+}
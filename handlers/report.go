@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type ReportHandler struct {
+	service services.ReportService
+}
+
+func NewReportHandler(service services.ReportService) *ReportHandler {
+	return &ReportHandler{service}
+}
+
+// parseDateRange reads the from and to dates (YYYY-MM-DD) of a report from
+// the query string, writing a 400 response when one is invalid. Missing
+// dates are left zero.
+func parseDateRange(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	for _, p := range []struct {
+		name string
+		date *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid "+p.name+", expected YYYY-MM-DD")
+			return from, to, false
+		}
+		*p.date = d
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		utils.ResponseError(w, http.StatusBadRequest, "to cannot be before from")
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// SalesReport godoc
+// @Summary      Sales report
+// @Description  Revenue, refunds, transaction count, items sold, discounts and tax of paid sales from the start of from to the end of to in the store timezone (STORE_TIMEZONE), grouped by day, hour, cashier or payment method. Both dates default to today. Refunds are what has been refunded for returns of those sales so far; net revenue is revenue minus refunds. Meta holds the totals of the whole period. Grouped by payment method, rows only have the revenue, what was paid with each method net of change, and the refunds made by it; transactions, items sold, discounts and tax are left out because a sale split across tenders can't be counted under one method.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from      query     string  false  "First day (YYYY-MM-DD)"
+// @Param        to        query     string  false  "Last day (YYYY-MM-DD)"
+// @Param        group_by  query     string  false  "Grouping"  Enums(day, hour, cashier, payment_method)  default(day)
+// @Success      200       {object}  utils.APIResponse{data=[]models.SalesReportRow,meta=models.SalesReportTotals}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/reports/sales [get]
+func (h *ReportHandler) SalesReport(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseDateRange(w, r)
+	if !ok {
+		return
+	}
+
+	groupBy := models.SalesByDay
+	if v := r.URL.Query().Get("group_by"); v != "" {
+		groupBy = models.SalesGroupBy(v)
+		if !groupBy.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "group_by must be one of day, hour, cashier, payment_method")
+			return
+		}
+	}
+
+	rows, totals, err := h.service.SalesReport(models.SalesReportRequest{From: from, To: to, GroupBy: groupBy})
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Sales report retrieved successfully", rows, totals)
+}
+
+// parseCategoryID reads an optional category_id from the query string,
+// writing a 400 response when it is invalid.
+func parseCategoryID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("category_id")
+	if v == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid category_id")
+		return 0, false
+	}
+	return id, true
+}
+
+// TopProducts godoc
+// @Summary      Best-selling products
+// @Description  The limit best-selling products of the paid sales from the start of from to the end of to in the store timezone, ranked by quantity sold or revenue. Both dates default to today. Revenue is net of discounts and excludes tax and service charge. category_id narrows the ranking to a category and its subcategories; per_category ranks the products of each category separately, returning up to limit per category.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from          query     string  false  "First day (YYYY-MM-DD)"
+// @Param        to            query     string  false  "Last day (YYYY-MM-DD)"
+// @Param        by            query     string  false  "Ranking"  Enums(quantity, revenue)  default(quantity)
+// @Param        limit         query     int     false  "Products to return (per category with per_category)"  default(10)
+// @Param        category_id   query     int     false  "Category, including its subcategories"
+// @Param        per_category  query     bool    false  "Rank each category separately"
+// @Success      200           {object}  utils.APIResponse{data=[]models.TopProduct,meta=models.TopProductsMeta}
+// @Failure      400           {object}  utils.APIResponse
+// @Failure      500           {object}  utils.APIResponse
+// @Router       /api/reports/top-products [get]
+func (h *ReportHandler) TopProducts(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseDateRange(w, r)
+	if !ok {
+		return
+	}
+	categoryID, ok := parseCategoryID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	req := models.TopProductsRequest{From: from, To: to, By: models.RankByQuantity, CategoryID: categoryID}
+
+	if v := query.Get("by"); v != "" {
+		req.By = models.ProductRanking(v)
+		if !req.By.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "by must be quantity or revenue")
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			utils.ResponseError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		req.Limit = limit
+	}
+	if v := query.Get("per_category"); v != "" {
+		perCategory, err := strconv.ParseBool(v)
+		if err != nil {
+			utils.ResponseError(w, http.StatusBadRequest, "Invalid per_category")
+			return
+		}
+		req.PerCategory = perCategory
+	}
+
+	products, meta, err := h.service.TopProducts(req)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Top products retrieved successfully", products, meta)
+}
+
+// SlowMovers godoc
+// @Summary      Slow-moving products
+// @Description  Products without paid sales today or in the days before it, with their current stock and its value at the selling price, most stock value first. Products that were never sold have no last_sold_at. Deleted products are left out.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        days         query     int  false  "Days without sales before today"  default(30)
+// @Param        category_id  query     int  false  "Category, including its subcategories"
+// @Param        page         query     int  false  "Page number"  default(1)
+// @Param        page_size    query     int  false  "Page size"  default(10)
+// @Success      200          {object}  utils.APIResponse{data=[]models.SlowMover,meta=utils.PaginationMeta}
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      500          {object}  utils.APIResponse
+// @Router       /api/reports/slow-movers [get]
+func (h *ReportHandler) SlowMovers(w http.ResponseWriter, r *http.Request) {
+	categoryID, ok := parseCategoryID(w, r)
+	if !ok {
+		return
+	}
+	req := models.SlowMoversRequest{Days: 30, CategoryID: categoryID}
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 || days > 3650 {
+			utils.ResponseError(w, http.StatusBadRequest, "days must be between 0 and 3650")
+			return
+		}
+		req.Days = days
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	products, meta, err := h.service.SlowMovers(req, page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Slow-moving products retrieved successfully", products, meta)
+}
+
+// MarginReport godoc
+// @Summary      Gross margin report
+// @Description  Revenue, cost of goods sold and gross profit of paid sales from the start of from to the end of to in the store timezone, grouped by product, category, day or month. Both dates default to today. Revenue is net of discounts and excludes tax and service charge. Returned units and their refunds are taken off the lines they return. Each sale line is costed from the cost history of its product or variant as it was when sold: at the last cost, or at the cumulative purchase average, the average of all costs recorded by then weighted by their quantities. Sales made before their product had a cost are reported as uncosted and left out of gross profit and margin, which is in basis points of costed revenue. Meta holds the totals.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from         query     string  false  "First day (YYYY-MM-DD)"
+// @Param        to           query     string  false  "Last day (YYYY-MM-DD)"
+// @Param        group_by     query     string  false  "Grouping"  Enums(product, category, day, month)  default(product)
+// @Param        method       query     string  false  "Costing method"  Enums(last, weighted_average)  default(weighted_average)
+// @Param        category_id  query     int     false  "Category, including its subcategories"
+// @Success      200          {object}  utils.APIResponse{data=[]models.MarginReportRow,meta=models.MarginReportTotals}
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      500          {object}  utils.APIResponse
+// @Router       /api/reports/margin [get]
+func (h *ReportHandler) MarginReport(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseDateRange(w, r)
+	if !ok {
+		return
+	}
+	categoryID, ok := parseCategoryID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	req := models.MarginReportRequest{
+		From:       from,
+		To:         to,
+		GroupBy:    models.MarginByProduct,
+		Method:     models.CostWeightedAverage,
+		CategoryID: categoryID,
+	}
+
+	if v := query.Get("group_by"); v != "" {
+		req.GroupBy = models.MarginGroupBy(v)
+		if !req.GroupBy.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "group_by must be one of product, category, day, month")
+			return
+		}
+	}
+	if v := query.Get("method"); v != "" {
+		req.Method = models.CostMethod(v)
+		if !req.Method.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "method must be last or weighted_average")
+			return
+		}
+	}
+
+	rows, totals, err := h.service.MarginReport(req)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Margin report retrieved successfully", rows, totals)
+}
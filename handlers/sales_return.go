@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type SalesReturnHandler struct {
+	service services.SalesReturnService
+}
+
+func NewSalesReturnHandler(service services.SalesReturnService) *SalesReturnHandler {
+	return &SalesReturnHandler{service}
+}
+
+// CreateReturn godoc
+// @Summary      Return items from a sale
+// @Description  Return some or all items of a transaction, restocking them and recording the refund. Only paid transactions can be returned. The refund is paid by refund_method (default cash); a cash refund comes out of the drawer of the signed-in user's open shift.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int                   true  "Transaction ID"
+// @Param        return  body      models.ReturnRequest  true  "Returned lines"
+// @Success      201     {object}  utils.APIResponse{data=models.SalesReturn}
+// @Failure      400     {object}  utils.APIResponse
+// @Failure      404     {object}  utils.APIResponse
+// @Failure      409     {object}  utils.APIResponse
+// @Failure      500     {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/returns [post]
+func (h *SalesReturnHandler) CreateReturn(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.ReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Reason == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Reason is required")
+		return
+	}
+	if req.RefundMethod == "" {
+		req.RefundMethod = models.PaymentCash
+	}
+	if !req.RefundMethod.Valid() {
+		utils.ResponseError(w, http.StatusBadRequest, "Refund method must be one of cash, debit_card, credit_card, e_wallet")
+		return
+	}
+	if len(req.Items) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Items are required")
+		return
+	}
+	for _, item := range req.Items {
+		if item.TransactionDetailID <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Transaction detail ID is required")
+			return
+		}
+		if item.Quantity <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+			return
+		}
+	}
+
+	salesReturn, err := h.service.CreateReturn(user.ID, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTransactionNotFound):
+			utils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrTransactionDetailNotFound):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrReturnExceedsSold), errors.Is(err, models.ErrTransactionNotPaid),
+			errors.Is(err, models.ErrNoOpenShift):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseCreated(w, "Return recorded successfully", salesReturn)
+}
+
+// ListReturns godoc
+// @Summary      List returns of a sale
+// @Description  Get all returns recorded against a transaction
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Transaction ID"
+// @Success      200  {object}  utils.APIResponse{data=[]models.SalesReturn}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/returns [get]
+func (h *SalesReturnHandler) ListReturns(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	returns, err := h.service.GetReturnsByTransactionID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(w, "Returns retrieved successfully", returns)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/middleware"
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type ShiftHandler struct {
+	service services.ShiftService
+}
+
+func NewShiftHandler(service services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service}
+}
+
+// currentUser returns the authenticated user, writing a 401 response when
+// there is none.
+func currentUser(w http.ResponseWriter, r *http.Request) (*models.AuthUser, bool) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		utils.ResponseError(w, http.StatusUnauthorized, "Missing bearer token")
+	}
+	return user, ok
+}
+
+func respondShiftError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrShiftAlreadyOpen), errors.Is(err, models.ErrNoOpenShift):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ListShifts godoc
+// @Summary      Show all shifts
+// @Description  Get all cashier shifts, newest first, with their expected and counted cash and variance
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.Shift}
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/shifts [get]
+func (h *ShiftHandler) ListShifts(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	shifts, meta, err := h.service.GetAllShifts(page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Shifts retrieved successfully", shifts, meta)
+}
+
+// GetShift godoc
+// @Summary      Get a shift
+// @Description  Get a cashier shift with its cash movements
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Shift ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Shift}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/shifts/{id} [get]
+func (h *ShiftHandler) GetShift(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	shift, err := h.service.GetShiftByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if shift == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Shift not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Shift retrieved successfully", shift)
+}
+
+// OpenShift godoc
+// @Summary      Open a shift
+// @Description  Open a shift for the signed-in user with the cash in the drawer. Sales can only be made during an open shift.
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        shift  body      models.OpenShiftRequest  true  "Opening cash"
+// @Success      201    {object}  utils.APIResponse{data=models.Shift}
+// @Failure      400    {object}  utils.APIResponse
+// @Failure      409    {object}  utils.APIResponse
+// @Failure      500    {object}  utils.APIResponse
+// @Router       /api/shifts [post]
+func (h *ShiftHandler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.OpeningCash < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Opening cash cannot be negative")
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+
+	shift, err := h.service.OpenShift(user.ID, req)
+	if err != nil {
+		respondShiftError(w, err)
+		return
+	}
+
+	utils.ResponseCreated(w, "Shift opened successfully", shift)
+}
+
+// GetCurrentShift godoc
+// @Summary      Get the current shift
+// @Description  Get the signed-in user's open shift with its expected cash so far
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.APIResponse{data=models.Shift}
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/shifts/current [get]
+func (h *ShiftHandler) GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	shift, err := h.service.GetOpenShift(user.ID)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if shift == nil {
+		utils.ResponseError(w, http.StatusNotFound, "No open shift")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Shift retrieved successfully", shift)
+}
+
+// AddCashMovement godoc
+// @Summary      Record cash in or out
+// @Description  Record cash put into (cash_in) or taken out of (cash_out) the drawer during the signed-in user's open shift, e.g. a float top-up, a bank drop or a cash refund
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        movement  body      models.CashMovementRequest  true  "Cash movement"
+// @Success      201       {object}  utils.APIResponse{data=models.Shift}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/shifts/current/cash-movements [post]
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.Type.Valid() {
+		utils.ResponseError(w, http.StatusBadRequest, "Type must be cash_in or cash_out")
+		return
+	}
+	if req.Amount <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Amount must be greater than 0")
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Reason is required")
+		return
+	}
+
+	shift, err := h.service.AddCashMovement(user.ID, req)
+	if err != nil {
+		respondShiftError(w, err)
+		return
+	}
+
+	utils.ResponseCreated(w, "Cash movement recorded successfully", shift)
+}
+
+// CloseShift godoc
+// @Summary      Close the current shift
+// @Description  Close the signed-in user's open shift with the cash counted in the drawer. The variance is counted minus expected cash, negative when the drawer is short.
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        shift  body      models.CloseShiftRequest  true  "Counted cash"
+// @Success      200    {object}  utils.APIResponse{data=models.Shift}
+// @Failure      400    {object}  utils.APIResponse
+// @Failure      409    {object}  utils.APIResponse
+// @Failure      500    {object}  utils.APIResponse
+// @Router       /api/shifts/current/close [post]
+func (h *ShiftHandler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.CountedCash < 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Counted cash cannot be negative")
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+
+	shift, err := h.service.CloseShift(user.ID, req)
+	if err != nil {
+		respondShiftError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Shift closed successfully", shift)
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type StockCountHandler struct {
+	service services.StockCountService
+}
+
+func NewStockCountHandler(service services.StockCountService) *StockCountHandler {
+	return &StockCountHandler{service}
+}
+
+func respondStockCountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrStockCountNotFound):
+		utils.ResponseError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, models.ErrProductNotFound), errors.Is(err, models.ErrProductOutOfCountScope),
+		errors.Is(err, models.ErrVariantNotFound), errors.Is(err, models.ErrVariantRequired),
+		errors.Is(err, models.ErrVariantNotAllowed):
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrStockCountAlreadyOpen), errors.Is(err, models.ErrStockCountNotOpen),
+		errors.Is(err, models.ErrNegativeStock):
+		utils.ResponseError(w, http.StatusConflict, err.Error())
+	default:
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ListStockCounts godoc
+// @Summary      Show all stock counts
+// @Description  Get all stock count (stock opname) sessions, newest first
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.StockCount}
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/stock-counts [get]
+func (h *StockCountHandler) ListStockCounts(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	counts, meta, err := h.service.GetAllStockCounts(page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Stock counts retrieved successfully", counts, meta)
+}
+
+// OpenStockCount godoc
+// @Summary      Open a stock count
+// @Description  Start a stock count session for all products or one category
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        stock_count  body      models.OpenStockCountRequest  true  "Scope"
+// @Success      201          {object}  utils.APIResponse{data=models.StockCount}
+// @Failure      400          {object}  utils.APIResponse
+// @Failure      409          {object}  utils.APIResponse
+// @Failure      500          {object}  utils.APIResponse
+// @Router       /api/stock-counts [post]
+func (h *StockCountHandler) OpenStockCount(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenStockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.CategoryID != nil && *req.CategoryID <= 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	count, err := h.service.OpenStockCount(req)
+	if err != nil {
+		respondStockCountError(w, err)
+		return
+	}
+
+	utils.ResponseCreated(w, "Stock count opened successfully", count)
+}
+
+// GetStockCount godoc
+// @Summary      Get a stock count
+// @Description  Get a stock count session with counted items and their variance
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Stock count ID"
+// @Success      200  {object}  utils.APIResponse{data=models.StockCount}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/stock-counts/{id} [get]
+func (h *StockCountHandler) GetStockCount(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	count, err := h.service.GetStockCountByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if count == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Stock count not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Stock count retrieved successfully", count)
+}
+
+// SubmitCounts godoc
+// @Summary      Submit counted quantities
+// @Description  Record counted quantities and snapshot current stock; resubmitting a product overwrites both
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int                             true  "Stock count ID"
+// @Param        counts  body      models.SubmitStockCountRequest  true  "Counted quantities"
+// @Success      200     {object}  utils.APIResponse{data=models.StockCount}
+// @Failure      400     {object}  utils.APIResponse
+// @Failure      404     {object}  utils.APIResponse
+// @Failure      409     {object}  utils.APIResponse
+// @Failure      500     {object}  utils.APIResponse
+// @Router       /api/stock-counts/{id}/items [put]
+func (h *StockCountHandler) SubmitCounts(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.SubmitStockCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.Items) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Items are required")
+		return
+	}
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Product ID is required")
+			return
+		}
+		if item.CountedQuantity < 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Counted quantity cannot be negative")
+			return
+		}
+	}
+
+	count, err := h.service.SubmitCounts(id, req)
+	if err != nil {
+		respondStockCountError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Counts submitted successfully", count)
+}
+
+// ApproveStockCount godoc
+// @Summary      Approve a stock count
+// @Description  Post adjustment movements for all variances against the counted snapshots and close the session
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Stock count ID"
+// @Success      200  {object}  utils.APIResponse{data=models.StockCount}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/stock-counts/{id}/approve [post]
+func (h *StockCountHandler) ApproveStockCount(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	count, err := h.service.ApproveStockCount(id)
+	if err != nil {
+		respondStockCountError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Stock count approved successfully", count)
+}
+
+// CancelStockCount godoc
+// @Summary      Cancel a stock count
+// @Description  Close the session without adjusting stock
+// @Tags         stock-counts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Stock count ID"
+// @Success      200  {object}  utils.APIResponse{data=models.StockCount}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/stock-counts/{id}/cancel [post]
+func (h *StockCountHandler) CancelStockCount(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	count, err := h.service.CancelStockCount(id)
+	if err != nil {
+		respondStockCountError(w, err)
+		return
+	}
+
+	utils.ResponseSuccess(w, "Stock count cancelled successfully", count)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type StockMovementHandler struct {
+	service services.StockMovementService
+}
+
+func NewStockMovementHandler(service services.StockMovementService) *StockMovementHandler {
+	return &StockMovementHandler{service}
+}
+
+// ListStockMovements godoc
+// @Summary      Show stock movements of a product
+// @Description  Get the stock ledger of a product, newest first, with pagination
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int  true   "Product ID"
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.StockMovement}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/products/{id}/stock-movements [get]
+func (h *StockMovementHandler) ListStockMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	movements, meta, err := h.service.GetMovementsByProductID(id, page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Stock movements retrieved successfully", movements, meta)
+}
+
+// AdjustStock godoc
+// @Summary      Record a stock movement
+// @Description  Add or remove stock through the ledger (adjustment, purchase or transfer). Products with variants need a variant_id.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      int                            true  "Product ID"
+// @Param        adjustment  body      models.StockAdjustmentRequest  true  "Signed quantity and reason"
+// @Success      201         {object}  utils.APIResponse{data=models.StockMovement}
+// @Failure      400         {object}  utils.APIResponse
+// @Failure      404         {object}  utils.APIResponse
+// @Failure      409         {object}  utils.APIResponse
+// @Failure      500         {object}  utils.APIResponse
+// @Router       /api/products/{id}/stock-movements [post]
+func (h *StockMovementHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.StockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = models.StockMovementAdjustment
+	}
+	switch req.Type {
+	case models.StockMovementAdjustment, models.StockMovementPurchase, models.StockMovementTransfer:
+	default:
+		// Sales and returns are only booked by checkout and the returns flow.
+		utils.ResponseError(w, http.StatusBadRequest, "Type must be adjustment, purchase or transfer")
+		return
+	}
+	if req.Quantity == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Quantity cannot be zero")
+		return
+	}
+	if req.Note == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Note is required")
+		return
+	}
+
+	movement, err := h.service.AdjustStock(id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrProductNotFound):
+			utils.ResponseError(w, http.StatusNotFound, "Product not found")
+		case errors.Is(err, models.ErrVariantNotFound):
+			utils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrVariantRequired), errors.Is(err, models.ErrVariantNotAllowed):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrNegativeStock), errors.Is(err, models.ErrStockCountInProgress):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseCreated(w, "Stock movement recorded successfully", movement)
+}
+
+// ListDiscrepancies godoc
+// @Summary      Reconcile stock with the ledger
+// @Description  List products whose stock differs from the sum of their stock movements
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.APIResponse{data=[]models.StockDiscrepancy}
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/inventory/reconciliation [get]
+func (h *StockMovementHandler) ListDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	discrepancies, err := h.service.GetDiscrepancies()
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccess(w, "Stock reconciliation retrieved successfully", discrepancies)
+}
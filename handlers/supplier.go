@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type SupplierHandler struct {
+	service services.SupplierService
+}
+
+func NewSupplierHandler(service services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service}
+}
+
+// ListSuppliers godoc
+// @Summary      Show all suppliers
+// @Description  Get all suppliers with pagination
+// @Tags         suppliers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.Supplier}
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/suppliers [get]
+func (h *SupplierHandler) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	suppliers, meta, err := h.service.GetAllSuppliers(page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Suppliers retrieved successfully", suppliers, meta)
+}
+
+// CreateSupplier godoc
+// @Summary      Create a new supplier
+// @Description  Create a new supplier with the input payload
+// @Tags         suppliers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        supplier  body      models.Supplier  true  "Supplier"
+// @Success      201       {object}  utils.APIResponse{data=models.Supplier}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/suppliers [post]
+func (h *SupplierHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if supplier.Name == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	createdSupplier, err := h.service.CreateSupplier(supplier)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseCreated(w, "Supplier created successfully", createdSupplier)
+}
+
+// GetSupplier godoc
+// @Summary      Get a supplier
+// @Description  Get supplier by ID
+// @Tags         suppliers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Supplier ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Supplier}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/suppliers/{id} [get]
+func (h *SupplierHandler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	supplier, err := h.service.GetSupplierByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if supplier == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Supplier not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Supplier retrieved successfully", supplier)
+}
+
+// UpdateSupplier godoc
+// @Summary      Update a supplier
+// @Description  Update supplier by ID
+// @Tags         suppliers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int              true  "Supplier ID"
+// @Param        supplier  body      models.Supplier  true  "Supplier"
+// @Success      200       {object}  utils.APIResponse{data=models.Supplier}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/suppliers/{id} [put]
+func (h *SupplierHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var supplier models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&supplier); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if supplier.Name == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	updatedSupplier, err := h.service.UpdateSupplier(id, supplier)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if updatedSupplier == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Supplier not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Supplier updated successfully", updatedSupplier)
+}
+
+// DeleteSupplier godoc
+// @Summary      Delete a supplier
+// @Description  Delete supplier by ID
+// @Tags         suppliers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Supplier ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/suppliers/{id} [delete]
+func (h *SupplierHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteSupplier(id)
+	if err != nil {
+		if errors.Is(err, models.ErrSupplierInUse) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseSuccess(w, "Supplier deleted successfully", nil)
+}
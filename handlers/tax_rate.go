@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type TaxRateHandler struct {
+	service services.TaxRateService
+}
+
+func NewTaxRateHandler(service services.TaxRateService) *TaxRateHandler {
+	return &TaxRateHandler{service}
+}
+
+// decodeTaxRate reads and validates a tax rate from the request body,
+// writing a 400 response when it is invalid.
+func decodeTaxRate(w http.ResponseWriter, r *http.Request) (models.TaxRate, bool) {
+	var rate models.TaxRate
+	if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return rate, false
+	}
+
+	rate.Name = strings.TrimSpace(rate.Name)
+	if rate.Name == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
+		return rate, false
+	}
+	if rate.Rate < 0 || rate.Rate > 10000 {
+		utils.ResponseError(w, http.StatusBadRequest, "Rate must be between 0 and 10000 basis points")
+		return rate, false
+	}
+	return rate, true
+}
+
+// ListTaxRates godoc
+// @Summary      Show all tax rates
+// @Description  Get all tax rates. Rates are in basis points (1100 = 11%).
+// @Tags         tax-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.APIResponse{data=[]models.TaxRate}
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/tax-rates [get]
+func (h *TaxRateHandler) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.GetAllTaxRates()
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccess(w, "Tax rates retrieved successfully", rates)
+}
+
+// CreateTaxRate godoc
+// @Summary      Create a tax rate
+// @Description  Create a tax rate such as PPN. Rate is in basis points (1100 = 11%); an inclusive rate is already part of the selling price, an exclusive one is added at checkout.
+// @Tags         tax-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        tax_rate  body      models.TaxRate  true  "Tax rate"
+// @Success      201       {object}  utils.APIResponse{data=models.TaxRate}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/tax-rates [post]
+func (h *TaxRateHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	rate, ok := decodeTaxRate(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.service.CreateTaxRate(rate)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseCreated(w, "Tax rate created successfully", created)
+}
+
+// GetTaxRate godoc
+// @Summary      Get a tax rate
+// @Description  Get tax rate by ID
+// @Tags         tax-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Tax rate ID"
+// @Success      200  {object}  utils.APIResponse{data=models.TaxRate}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/tax-rates/{id} [get]
+func (h *TaxRateHandler) GetTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	rate, err := h.service.GetTaxRateByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rate == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Tax rate not found")
+		return
+	}
+	utils.ResponseSuccess(w, "Tax rate retrieved successfully", rate)
+}
+
+// UpdateTaxRate godoc
+// @Summary      Update a tax rate
+// @Description  Update a tax rate. Past sales keep the rate they were taxed at.
+// @Tags         tax-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int             true  "Tax rate ID"
+// @Param        tax_rate  body      models.TaxRate  true  "Tax rate"
+// @Success      200       {object}  utils.APIResponse{data=models.TaxRate}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/tax-rates/{id} [put]
+func (h *TaxRateHandler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	rate, ok := decodeTaxRate(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.UpdateTaxRate(id, rate)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if updated == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Tax rate not found")
+		return
+	}
+	utils.ResponseSuccess(w, "Tax rate updated successfully", updated)
+}
+
+// DeleteTaxRate godoc
+// @Summary      Delete a tax rate
+// @Description  Delete a tax rate that no product or category uses
+// @Tags         tax-rates
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Tax rate ID"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/tax-rates/{id} [delete]
+func (h *TaxRateHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTaxRate(id); err != nil {
+		switch {
+		case errors.Is(err, models.ErrTaxRateNotFound):
+			utils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrTaxRateInUse):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	utils.ResponseSuccess(w, "Tax rate deleted successfully", nil)
+}
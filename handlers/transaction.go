@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type TransactionHandler struct {
+	service services.TransactionService
+}
+
+func NewTransactionHandler(service services.TransactionService) *TransactionHandler {
+	return &TransactionHandler{service}
+}
+
+// validatePayments checks the tenders of a payment, writing a 400 response
+// when they are invalid.
+func validatePayments(w http.ResponseWriter, payments []models.PaymentRequest) bool {
+	for i, p := range payments {
+		if !p.Method.Valid() {
+			utils.ResponseError(w, http.StatusBadRequest, "Payment method must be one of cash, debit_card, credit_card, e_wallet")
+			return false
+		}
+		if p.Amount <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Payment amount must be greater than 0")
+			return false
+		}
+		payments[i].Reference = strings.TrimSpace(p.Reference)
+	}
+	return true
+}
+
+// Checkout godoc
+// @Summary      Checkout a cart
+// @Description  Create a sales transaction and decrement product stock atomically. Active promotions and the given voucher codes are applied; each line lists the discounts it received. Payments may mix tenders and must cover the total; only cash can be overpaid, and the excess is returned as change. Without payments the transaction is stored unpaid. The sale is recorded in the signed-in user's open shift.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        checkout  body      models.CheckoutRequest  true  "Cart items"
+// @Success      201       {object}  utils.APIResponse{data=models.Transaction}
+// @Failure      400       {object}  utils.APIResponse
+// @Failure      402       {object}  utils.APIResponse
+// @Failure      404       {object}  utils.APIResponse
+// @Failure      409       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/checkout [post]
+func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.Items) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Items are required")
+		return
+	}
+	for _, item := range req.Items {
+		if item.ProductID <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Product ID is required")
+			return
+		}
+		if item.Quantity <= 0 {
+			utils.ResponseError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+			return
+		}
+	}
+	for i, code := range req.PromoCodes {
+		req.PromoCodes[i] = normalizePromoCode(code)
+		if req.PromoCodes[i] == "" {
+			utils.ResponseError(w, http.StatusBadRequest, "Promo codes cannot be empty")
+			return
+		}
+	}
+	if !validatePayments(w, req.Payments) {
+		return
+	}
+
+	transaction, err := h.service.Checkout(user.ID, req)
+	if err != nil {
+		if respondPaymentError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, models.ErrProductNotFound), errors.Is(err, models.ErrVariantNotFound):
+			utils.ResponseError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, models.ErrVariantRequired), errors.Is(err, models.ErrInvalidPromoCode):
+			utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, models.ErrInsufficientStock), errors.Is(err, models.ErrNoOpenShift):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.ResponseCreated(w, "Checkout successful", transaction)
+}
+
+// GetTransaction godoc
+// @Summary      Get a transaction
+// @Description  Get transaction with its line items by ID
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Transaction ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Transaction}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/transactions/{id} [get]
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	transaction, err := h.service.GetTransactionByID(id)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if transaction == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Transaction retrieved successfully", transaction)
+}
+
+// PayTransaction godoc
+// @Summary      Pay a transaction
+// @Description  Take payment for a transaction that was checked out unpaid, into the signed-in user's open shift. Payments may mix tenders and must cover the total; only cash can be overpaid, and the excess is returned as change.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                true  "Transaction ID"
+// @Param        payment  body      models.PayRequest  true  "Tenders"
+// @Success      200      {object}  utils.APIResponse{data=models.Transaction}
+// @Failure      400      {object}  utils.APIResponse
+// @Failure      402      {object}  utils.APIResponse
+// @Failure      404      {object}  utils.APIResponse
+// @Failure      409      {object}  utils.APIResponse
+// @Failure      500      {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/payments [post]
+func (h *TransactionHandler) PayTransaction(w http.ResponseWriter, r *http.Request) {
+	user, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	var req models.PayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Payments) == 0 {
+		utils.ResponseError(w, http.StatusBadRequest, "Payments are required")
+		return
+	}
+	if !validatePayments(w, req.Payments) {
+		return
+	}
+
+	transaction, err := h.service.Pay(user.ID, id, req)
+	if err != nil {
+		if respondPaymentError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, models.ErrNoOpenShift):
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+		default:
+			utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if transaction == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Payment successful", transaction)
+}
+
+// CancelTransaction godoc
+// @Summary      Cancel an unpaid transaction
+// @Description  Call off a transaction that was checked out unpaid, e.g. when the customer walks away or abandons a QRIS payment. The stock of its lines is put back with cancellation movements. A QRIS payment that is still pending and not expired must be settled or expire first.
+// @Tags         transactions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Transaction ID"
+// @Success      200  {object}  utils.APIResponse{data=models.Transaction}
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      409  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /api/transactions/{id}/cancel [post]
+func (h *TransactionHandler) CancelTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.ParseIDFromRequest(r, w)
+	if !ok {
+		return
+	}
+
+	transaction, err := h.service.Cancel(id)
+	if err != nil {
+		if respondPaymentError(w, err) {
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if transaction == nil {
+		utils.ResponseError(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+
+	utils.ResponseSuccess(w, "Transaction cancelled successfully", transaction)
+}
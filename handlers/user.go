@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type UserHandler struct {
+	service services.UserService
+}
+
+func NewUserHandler(service services.UserService) *UserHandler {
+	return &UserHandler{service}
+}
+
+// ListUsers godoc
+// @Summary      Show all users
+// @Description  Get all staff accounts with pagination
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page      query     int  false  "Page number" default(1)
+// @Param        page_size query     int  false  "Page size" default(10)
+// @Success      200       {object}  utils.APIResponse{data=[]models.User}
+// @Failure      401       {object}  utils.APIResponse
+// @Failure      403       {object}  utils.APIResponse
+// @Failure      500       {object}  utils.APIResponse
+// @Router       /api/users [get]
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+
+	users, meta, err := h.service.GetAllUsers(page, pageSize)
+	if err != nil {
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.ResponseSuccessWithMeta(w, "Users retrieved successfully", users, meta)
+}
+
+// CreateUser godoc
+// @Summary      Create a new user
+// @Description  Create a staff account; the password is stored as a bcrypt hash
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user  body      models.User  true  "User"
+// @Success      201   {object}  utils.APIResponse{data=models.User}
+// @Failure      400   {object}  utils.APIResponse
+// @Failure      401   {object}  utils.APIResponse
+// @Failure      403   {object}  utils.APIResponse
+// @Failure      409   {object}  utils.APIResponse
+// @Failure      500   {object}  utils.APIResponse
+// @Router       /api/users [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		utils.ResponseError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if user.Username == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+	if user.Name == "" {
+		utils.ResponseError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	if user.Role == "" {
+		user.Role = models.RoleCashier
+	}
+	if !user.Role.Valid() {
+		utils.ResponseError(w, http.StatusBadRequest, "Role must be owner, manager or cashier")
+		return
+	}
+	if len(user.Password) < 8 {
+		utils.ResponseError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	createdUser, err := h.service.CreateUser(user)
+	if err != nil {
+		if errors.Is(err, models.ErrUsernameTaken) {
+			utils.ResponseError(w, http.StatusConflict, err.Error())
+			return
+		}
+		utils.ResponseError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.ResponseCreated(w, "User created successfully", createdUser)
+}
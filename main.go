@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"kasir-api/database"
 	"kasir-api/handlers"
+	"kasir-api/middleware"
+	"kasir-api/models"
 	"kasir-api/repositories"
 	"kasir-api/services"
 	"kasir-api/utils"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
+	_ "time/tzdata"
 
 	_ "kasir-api/docs"
 
@@ -21,6 +28,11 @@ import (
 // @host            localhost:8093
 // @BasePath        /
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Type "Bearer" followed by a space and the access token.
+
 func main() {
 	config, err := utils.LoadConfig(".")
 	if err != nil {
@@ -33,37 +45,386 @@ func main() {
 	}
 	defer db.Close()
 
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal("cannot load migrations:", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Fatal("migration failed:", err)
+		}
+		return
+	}
+
+	if config.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal("migration failed:", err)
+		}
+	}
+
+	if config.JWTSecret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+	if config.ServiceChargeRate < 0 || config.ServiceChargeRate > 10000 {
+		log.Fatal("SERVICE_CHARGE_RATE must be between 0 and 10000 basis points")
+	}
+	if !models.RoundingMode(config.RoundingMode).Valid() {
+		log.Fatal("ROUNDING_MODE must be half_up, half_even or down")
+	}
+	if config.CashRounding < 0 {
+		log.Fatal("CASH_ROUNDING cannot be negative")
+	}
+
+	storeLocation, err := time.LoadLocation(config.StoreTimezone)
+	if err != nil || config.StoreTimezone == "Local" {
+		log.Fatal("STORE_TIMEZONE must be an IANA timezone such as Asia/Jakarta")
+	}
+	if !models.PaperWidth(config.ReceiptPaperWidth).Valid() {
+		log.Fatal("RECEIPT_PAPER_WIDTH must be 58 or 80")
+	}
+
+	var lowStockNotifier services.LowStockNotifier
+	switch config.LowStockNotifier {
+	case "log":
+		lowStockNotifier = services.LogNotifier{}
+	case "webhook":
+		if config.LowStockWebhookURL == "" {
+			log.Fatal("LOW_STOCK_WEBHOOK_URL must be set")
+		}
+		lowStockNotifier = &services.WebhookNotifier{
+			URL:    config.LowStockWebhookURL,
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		log.Fatal("LOW_STOCK_NOTIFIER must be log or webhook")
+	}
+
+	var qrisGateway services.QRISGateway
+	switch config.QRISGateway {
+	case "":
+	case "mock":
+		if config.QRISSecret == "" {
+			log.Fatal("QRIS_SECRET must be set")
+		}
+		if config.QRISExpiry <= 0 {
+			log.Fatal("QRIS_EXPIRY must be positive")
+		}
+		if len(config.QRISMerchantName) > 25 || len(config.QRISMerchantCity) > 15 {
+			log.Fatal("QRIS_MERCHANT_NAME must be at most 25 and QRIS_MERCHANT_CITY at most 15 characters")
+		}
+		qrisGateway = &services.MockQRISGateway{
+			Secret:       []byte(config.QRISSecret),
+			Expiry:       config.QRISExpiry,
+			MerchantName: config.QRISMerchantName,
+			MerchantCity: config.QRISMerchantCity,
+		}
+	default:
+		log.Fatal("QRIS_GATEWAY must be mock or empty")
+	}
+
+	// Dependency Injection - User & Auth
+	userRepo := repositories.NewUserRepository(db)
+	userService := services.NewUserService(userRepo)
+	userHandler := handlers.NewUserHandler(userService)
+	authService := services.NewAuthService(userRepo, services.AuthConfig{
+		Secret:          config.JWTSecret,
+		AccessTokenTTL:  config.AccessTokenTTL,
+		RefreshTokenTTL: config.RefreshTokenTTL,
+	})
+	authHandler := handlers.NewAuthHandler(authService)
+	auth := middleware.NewAuthMiddleware(authService)
+
+	if config.AdminUsername != "" && config.AdminPassword != "" {
+		err := userService.EnsureInitialUser(models.User{
+			Username: config.AdminUsername,
+			Name:     config.AdminUsername,
+			Role:     models.RoleOwner,
+			Password: config.AdminPassword,
+		})
+		if err != nil {
+			log.Fatal("cannot create initial user:", err)
+		}
+	}
+
 	// Dependency Injection - Product
 	productRepo := repositories.NewProductRepository(db)
 	productService := services.NewProductService(productRepo)
 	productHandler := handlers.NewProductHandler(productService)
+	productVariantRepo := repositories.NewProductVariantRepository(db)
+	productVariantService := services.NewProductVariantService(productVariantRepo)
+	productVariantHandler := handlers.NewProductVariantHandler(productVariantService)
+
+	// Dependency Injection - Stock Movement
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	stockMovementService := services.NewStockMovementService(stockMovementRepo)
+	stockMovementHandler := handlers.NewStockMovementHandler(stockMovementService)
+
+	// Dependency Injection - Product Cost
+	productCostRepo := repositories.NewProductCostRepository(db)
+	productCostService := services.NewProductCostService(productCostRepo)
+	productCostHandler := handlers.NewProductCostHandler(productCostService)
+
+	// Dependency Injection - Stock Count
+	stockCountRepo := repositories.NewStockCountRepository(db)
+	stockCountService := services.NewStockCountService(stockCountRepo)
+	stockCountHandler := handlers.NewStockCountHandler(stockCountService)
+
+	// Dependency Injection - Supplier & Purchase Order
+	supplierRepo := repositories.NewSupplierRepository(db)
+	supplierService := services.NewSupplierService(supplierRepo)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
 
 	// Dependency Injection - Category
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
+	// Dependency Injection - Promotion
+	promotionRepo := repositories.NewPromotionRepository(db)
+	promotionService := services.NewPromotionService(promotionRepo)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	// Dependency Injection - Tax Rate
+	taxRateRepo := repositories.NewTaxRateRepository(db)
+	taxRateService := services.NewTaxRateService(taxRateRepo)
+	taxRateHandler := handlers.NewTaxRateHandler(taxRateService)
+
+	// Dependency Injection - Shift
+	shiftRepo := repositories.NewShiftRepository(db)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+
+	// Dependency Injection - Low Stock
+	lowStockRepo := repositories.NewLowStockRepository(db)
+	lowStockService := services.NewLowStockService(lowStockRepo)
+	lowStockHandler := handlers.NewLowStockHandler(lowStockService)
+	lowStockChecker := services.NewLowStockChecker(lowStockRepo, lowStockNotifier, 1000)
+	go lowStockChecker.Run(context.Background())
+
+	// Dependency Injection - Transaction
+	// Tenders are taken at the counter until a card or e-wallet gateway is
+	// integrated as a PaymentProvider.
+	paymentProviders := services.PaymentProviders{
+		models.PaymentCash:       services.ManualPaymentProvider{},
+		models.PaymentDebitCard:  services.ManualPaymentProvider{},
+		models.PaymentCreditCard: services.ManualPaymentProvider{},
+		models.PaymentEWallet:    services.ManualPaymentProvider{},
+	}
+	transactionRepo := repositories.NewTransactionRepository(db)
+	transactionService := services.NewTransactionService(transactionRepo, promotionRepo, taxRateRepo, paymentProviders, lowStockChecker, services.PricingConfig{
+		ServiceChargeRate: config.ServiceChargeRate,
+		Rounding:          models.RoundingMode(config.RoundingMode),
+		CashRounding:      models.Money(config.CashRounding),
+	})
+	transactionHandler := handlers.NewTransactionHandler(transactionService)
+
+	// Dependency Injection - Receipt
+	receiptService := services.NewReceiptService(transactionRepo, shiftRepo, models.StoreInfo{
+		Name:     config.StoreName,
+		Address:  config.StoreAddress,
+		Phone:    config.StorePhone,
+		Footer:   config.ReceiptFooter,
+		Location: storeLocation,
+	}, models.PaperWidth(config.ReceiptPaperWidth))
+	receiptHandler := handlers.NewReceiptHandler(receiptService)
+
+	// Dependency Injection - Report
+	reportRepo := repositories.NewReportRepository(db)
+	reportService := services.NewReportService(reportRepo, storeLocation)
+	reportHandler := handlers.NewReportHandler(reportService)
+
+	// Dependency Injection - Payment
+	paymentRepo := repositories.NewPaymentRepository(db)
+	paymentService := services.NewPaymentService(paymentRepo, qrisGateway)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	// Dependency Injection - Sales Return
+	salesReturnRepo := repositories.NewSalesReturnRepository(db)
+	salesReturnService := services.NewSalesReturnService(salesReturnRepo)
+	salesReturnHandler := handlers.NewSalesReturnHandler(salesReturnService)
+
 	http.HandleFunc("/health", handlers.Health)
 
 	// Swagger
 	http.Handle("/swagger/", httpSwagger.WrapHandler)
 
+	// Auth Routes
+	http.HandleFunc("POST /api/auth/login", authHandler.Login)
+	http.HandleFunc("POST /api/auth/refresh", authHandler.Refresh)
+
+	// User Routes
+	http.HandleFunc("GET /api/users", auth.Require(models.PermUserManage, userHandler.ListUsers))
+	http.HandleFunc("POST /api/users", auth.Require(models.PermUserManage, userHandler.CreateUser))
+
 	// Product Routes
-	http.HandleFunc("GET /api/products", productHandler.ListProducts)
-	http.HandleFunc("POST /api/products", productHandler.CreateProduct)
-	http.HandleFunc("GET /api/products/{id}", productHandler.GetProduct)
-	http.HandleFunc("PUT /api/products/{id}", productHandler.UpdateProduct)
-	http.HandleFunc("DELETE /api/products/{id}", productHandler.DeleteProduct)
+	http.HandleFunc("GET /api/products", auth.Require(models.PermProductRead, productHandler.ListProducts))
+	http.HandleFunc("POST /api/products", auth.Require(models.PermProductWrite, productHandler.CreateProduct))
+	http.HandleFunc("GET /api/products/{id}", auth.Require(models.PermProductRead, productHandler.GetProduct))
+	http.HandleFunc("PUT /api/products/{id}", auth.Require(models.PermProductWrite, productHandler.UpdateProduct))
+	http.HandleFunc("DELETE /api/products/{id}", auth.Require(models.PermProductWrite, productHandler.DeleteProduct))
+	http.HandleFunc("POST /api/products/{id}/restore", auth.Require(models.PermProductWrite, productHandler.RestoreProduct))
+
+	// ServeMux rejects "GET /api/products/by-barcode/{code}" next to
+	// "GET /api/products/{id}/<resource>" patterns because they overlap, so
+	// both are dispatched from a single route.
+	productBarcodeLookup := auth.Require(models.PermProductRead, productHandler.GetProductByBarcode)
+	productSubresources := map[string]http.HandlerFunc{
+		"stock-movements": auth.Require(models.PermProductRead, stockMovementHandler.ListStockMovements),
+		"costs":           auth.Require(models.PermPurchaseManage, productCostHandler.ListProductCosts),
+		"variants":        auth.Require(models.PermProductRead, productVariantHandler.ListVariants),
+	}
+	http.HandleFunc("GET /api/products/{id}/{resource}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "by-barcode" {
+			r.SetPathValue("code", r.PathValue("resource"))
+			productBarcodeLookup(w, r)
+			return
+		}
+		handler, ok := productSubresources[r.PathValue("resource")]
+		if !ok {
+			utils.ResponseError(w, http.StatusNotFound, "Not found")
+			return
+		}
+		handler(w, r)
+	})
+	http.HandleFunc("POST /api/products/{id}/costs", auth.Require(models.PermPurchaseManage, productCostHandler.SetProductCost))
+	http.HandleFunc("POST /api/products/{id}/variants", auth.Require(models.PermProductWrite, productVariantHandler.CreateVariant))
+	http.HandleFunc("PUT /api/products/{id}/variants/{variant_id}", auth.Require(models.PermProductWrite, productVariantHandler.UpdateVariant))
+	http.HandleFunc("DELETE /api/products/{id}/variants/{variant_id}", auth.Require(models.PermProductWrite, productVariantHandler.DeleteVariant))
+
+	// Inventory Routes
+	http.HandleFunc("POST /api/products/{id}/stock-movements", auth.Require(models.PermInventoryManage, stockMovementHandler.AdjustStock))
+	http.HandleFunc("GET /api/inventory/reconciliation", auth.Require(models.PermInventoryManage, stockMovementHandler.ListDiscrepancies))
+	http.HandleFunc("GET /api/inventory/low-stock", auth.Require(models.PermInventoryManage, lowStockHandler.ListLowStock))
+
+	// Stock Count Routes
+	http.HandleFunc("GET /api/stock-counts", auth.Require(models.PermInventoryManage, stockCountHandler.ListStockCounts))
+	http.HandleFunc("POST /api/stock-counts", auth.Require(models.PermInventoryManage, stockCountHandler.OpenStockCount))
+	http.HandleFunc("GET /api/stock-counts/{id}", auth.Require(models.PermInventoryManage, stockCountHandler.GetStockCount))
+	http.HandleFunc("PUT /api/stock-counts/{id}/items", auth.Require(models.PermInventoryManage, stockCountHandler.SubmitCounts))
+	http.HandleFunc("POST /api/stock-counts/{id}/approve", auth.Require(models.PermInventoryManage, stockCountHandler.ApproveStockCount))
+	http.HandleFunc("POST /api/stock-counts/{id}/cancel", auth.Require(models.PermInventoryManage, stockCountHandler.CancelStockCount))
+
+	// Supplier Routes
+	http.HandleFunc("GET /api/suppliers", auth.Require(models.PermPurchaseManage, supplierHandler.ListSuppliers))
+	http.HandleFunc("POST /api/suppliers", auth.Require(models.PermPurchaseManage, supplierHandler.CreateSupplier))
+	http.HandleFunc("GET /api/suppliers/{id}", auth.Require(models.PermPurchaseManage, supplierHandler.GetSupplier))
+	http.HandleFunc("PUT /api/suppliers/{id}", auth.Require(models.PermPurchaseManage, supplierHandler.UpdateSupplier))
+	http.HandleFunc("DELETE /api/suppliers/{id}", auth.Require(models.PermPurchaseManage, supplierHandler.DeleteSupplier))
+
+	// Purchase Order Routes
+	http.HandleFunc("GET /api/purchase-orders", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.ListPurchaseOrders))
+	http.HandleFunc("POST /api/purchase-orders", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.CreatePurchaseOrder))
+	http.HandleFunc("GET /api/purchase-orders/{id}", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.GetPurchaseOrder))
+	http.HandleFunc("PUT /api/purchase-orders/{id}", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.UpdatePurchaseOrder))
+	http.HandleFunc("POST /api/purchase-orders/{id}/order", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.OrderPurchaseOrder))
+	http.HandleFunc("POST /api/purchase-orders/{id}/cancel", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.CancelPurchaseOrder))
+	http.HandleFunc("POST /api/purchase-orders/{id}/receive", auth.Require(models.PermPurchaseManage, purchaseOrderHandler.ReceivePurchaseOrder))
 
 	// Category Routes
-	http.HandleFunc("GET /api/categories", categoryHandler.ListCategories)
-	http.HandleFunc("POST /api/categories", categoryHandler.CreateCategory)
-	http.HandleFunc("GET /api/categories/{id}", categoryHandler.GetCategory)
-	http.HandleFunc("PUT /api/categories/{id}", categoryHandler.UpdateCategory)
-	http.HandleFunc("DELETE /api/categories/{id}", categoryHandler.DeleteCategory)
+	http.HandleFunc("GET /api/categories", auth.Require(models.PermCategoryRead, categoryHandler.ListCategories))
+	http.HandleFunc("POST /api/categories", auth.Require(models.PermCategoryWrite, categoryHandler.CreateCategory))
+	http.HandleFunc("GET /api/categories/tree", auth.Require(models.PermCategoryRead, categoryHandler.GetCategoryTree))
+	http.HandleFunc("GET /api/categories/{id}", auth.Require(models.PermCategoryRead, categoryHandler.GetCategory))
+	http.HandleFunc("PUT /api/categories/{id}", auth.Require(models.PermCategoryWrite, categoryHandler.UpdateCategory))
+	http.HandleFunc("DELETE /api/categories/{id}", auth.Require(models.PermCategoryWrite, categoryHandler.DeleteCategory))
+	http.HandleFunc("POST /api/categories/{id}/restore", auth.Require(models.PermCategoryWrite, categoryHandler.RestoreCategory))
+
+	// Promotion Routes
+	http.HandleFunc("GET /api/promotions", auth.Require(models.PermPromotionManage, promotionHandler.ListPromotions))
+	http.HandleFunc("POST /api/promotions", auth.Require(models.PermPromotionManage, promotionHandler.CreatePromotion))
+	http.HandleFunc("GET /api/promotions/{id}", auth.Require(models.PermPromotionManage, promotionHandler.GetPromotion))
+	http.HandleFunc("PUT /api/promotions/{id}", auth.Require(models.PermPromotionManage, promotionHandler.UpdatePromotion))
+	http.HandleFunc("DELETE /api/promotions/{id}", auth.Require(models.PermPromotionManage, promotionHandler.DeletePromotion))
+
+	// Tax Rate Routes
+	http.HandleFunc("GET /api/tax-rates", auth.Require(models.PermTaxManage, taxRateHandler.ListTaxRates))
+	http.HandleFunc("POST /api/tax-rates", auth.Require(models.PermTaxManage, taxRateHandler.CreateTaxRate))
+	http.HandleFunc("GET /api/tax-rates/{id}", auth.Require(models.PermTaxManage, taxRateHandler.GetTaxRate))
+	http.HandleFunc("PUT /api/tax-rates/{id}", auth.Require(models.PermTaxManage, taxRateHandler.UpdateTaxRate))
+	http.HandleFunc("DELETE /api/tax-rates/{id}", auth.Require(models.PermTaxManage, taxRateHandler.DeleteTaxRate))
+
+	// Shift Routes
+	http.HandleFunc("GET /api/shifts", auth.Require(models.PermShiftRead, shiftHandler.ListShifts))
+	http.HandleFunc("POST /api/shifts", auth.Require(models.PermSaleCreate, shiftHandler.OpenShift))
+	http.HandleFunc("GET /api/shifts/current", auth.Require(models.PermSaleCreate, shiftHandler.GetCurrentShift))
+	http.HandleFunc("POST /api/shifts/current/cash-movements", auth.Require(models.PermSaleCreate, shiftHandler.AddCashMovement))
+	http.HandleFunc("POST /api/shifts/current/close", auth.Require(models.PermSaleCreate, shiftHandler.CloseShift))
+	http.HandleFunc("GET /api/shifts/{id}", auth.Require(models.PermShiftRead, shiftHandler.GetShift))
+
+	// Transaction Routes
+	http.HandleFunc("POST /api/checkout", auth.Require(models.PermSaleCreate, transactionHandler.Checkout))
+	http.HandleFunc("GET /api/transactions/{id}", auth.Require(models.PermSaleRead, transactionHandler.GetTransaction))
+	http.HandleFunc("GET /api/transactions/{id}/receipt", auth.Require(models.PermSaleRead, receiptHandler.GetReceipt))
+	http.HandleFunc("POST /api/transactions/{id}/payments", auth.Require(models.PermSaleCreate, transactionHandler.PayTransaction))
+	http.HandleFunc("POST /api/transactions/{id}/cancel", auth.Require(models.PermSaleCreate, transactionHandler.CancelTransaction))
+	http.HandleFunc("POST /api/transactions/{id}/qris", auth.Require(models.PermSaleCreate, paymentHandler.CreateQRISPayment))
+	http.HandleFunc("POST /api/transactions/{id}/returns", auth.Require(models.PermSaleReturn, salesReturnHandler.CreateReturn))
+	http.HandleFunc("GET /api/transactions/{id}/returns", auth.Require(models.PermSaleRead, salesReturnHandler.ListReturns))
+
+	// Report Routes
+	http.HandleFunc("GET /api/reports/sales", auth.Require(models.PermReportRead, reportHandler.SalesReport))
+	http.HandleFunc("GET /api/reports/top-products", auth.Require(models.PermReportRead, reportHandler.TopProducts))
+	http.HandleFunc("GET /api/reports/slow-movers", auth.Require(models.PermReportRead, reportHandler.SlowMovers))
+	http.HandleFunc("GET /api/reports/margin", auth.Require(models.PermReportRead, reportHandler.MarginReport))
+
+	// Payment Routes
+	// The gateway authenticates callbacks by signing them, not with a token.
+	http.HandleFunc("POST /api/payments/callback", paymentHandler.QRISCallback)
 
 	fmt.Printf("Server running on http://localhost:%s\n", config.Port)
 	if err := http.ListenAndServe(":"+config.Port, nil); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runMigrate handles `kasir-api migrate [up|down [steps]|status]`.
+func runMigrate(migrator *database.Migrator, args []string) error {
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) applied\n", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) rolled back\n", len(rolledBack))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down or status)", command)
+	}
+	return nil
+}
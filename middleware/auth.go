@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+	"kasir-api/utils"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+type AuthMiddleware struct {
+	service services.AuthService
+}
+
+func NewAuthMiddleware(service services.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{service}
+}
+
+// Authenticate rejects requests without a valid "Authorization: Bearer"
+// access token and stores the authenticated user in the request context.
+func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			utils.ResponseError(w, http.StatusUnauthorized, "Missing bearer token")
+			return
+		}
+
+		user, err := m.service.ParseAccessToken(token)
+		if err != nil {
+			utils.ResponseError(w, http.StatusUnauthorized, models.ErrInvalidToken.Error())
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// UserFromContext returns the user stored by Authenticate, if any.
+func UserFromContext(ctx context.Context) (*models.AuthUser, bool) {
+	user, ok := ctx.Value(userContextKey).(*models.AuthUser)
+	return user, ok
+}
+
+// Require authenticates the request and then rejects it with 403 unless the
+// user's role grants the given permission.
+func (m *AuthMiddleware) Require(permission models.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return m.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok || !user.Role.Can(permission) {
+			utils.ResponseError(w, http.StatusForbidden, "You don't have permission to perform this action")
+			return
+		}
+		next(w, r)
+	})
+}
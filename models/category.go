@@ -1,6 +1,37 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 type Category struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *int       `json:"parent_id"`
+	TaxRateID *int       `json:"tax_rate_id"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CategoryNode is a category with its subcategories, as returned by the
+// category tree.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryInUseError reports the live products and subcategories still
+// in a category that was asked to be deleted. It matches ErrCategoryInUse
+// with errors.Is.
+type CategoryInUseError struct {
+	Products      int `json:"products"`
+	Subcategories int `json:"subcategories"`
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("%v: %d products, %d subcategories", ErrCategoryInUse, e.Products, e.Subcategories)
+}
+
+func (e *CategoryInUseError) Is(target error) bool {
+	return target == ErrCategoryInUse
 }
@@ -0,0 +1,74 @@
+package models
+
+import "errors"
+
+// Domain errors shared by repositories and services. Handlers map them to
+// HTTP status codes with errors.Is.
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrNegativeStock     = errors.New("stock cannot go below zero")
+	ErrSKUTaken          = errors.New("sku already used by another product")
+	ErrBarcodeTaken      = errors.New("barcode already used by another product")
+
+	ErrVariantNotFound      = errors.New("variant not found")
+	ErrVariantRequired      = errors.New("product has variants, a variant must be chosen")
+	ErrVariantNotAllowed    = errors.New("product has no variants")
+	ErrVariantOptions       = errors.New("variant options do not match the product options")
+	ErrVariantOptionsTaken  = errors.New("another variant already has these options")
+	ErrVariantInUse         = errors.New("variant has sales, purchase or stock count history")
+	ErrProductHasVariants   = errors.New("options cannot change while the product has variants")
+	ErrProductStockNotEmpty = errors.New("product stock must be zero before its first variant is added")
+
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or one of its subcategories")
+	ErrCategoryInUse          = errors.New("category is in use")
+	ErrCategoryDeleted        = errors.New("category is deleted")
+	ErrInvalidReassignTarget  = errors.New("invalid reassign_to category")
+
+	ErrStockCountNotFound     = errors.New("stock count not found")
+	ErrStockCountAlreadyOpen  = errors.New("another stock count is already open")
+	ErrStockCountNotOpen      = errors.New("stock count is not open")
+	ErrStockCountInProgress   = errors.New("product is locked by an open stock count")
+	ErrProductOutOfCountScope = errors.New("product is outside the stock count scope")
+
+	ErrSupplierNotFound               = errors.New("supplier not found")
+	ErrSupplierInUse                  = errors.New("supplier has purchase orders")
+	ErrPurchaseOrderNotFound          = errors.New("purchase order not found")
+	ErrPurchaseOrderItemNotFound      = errors.New("purchase order line not found")
+	ErrInvalidPurchaseOrderTransition = errors.New("purchase order status does not allow this action")
+	ErrReceiveExceedsOrdered          = errors.New("received quantity exceeds quantity ordered")
+
+	ErrTransactionNotFound       = errors.New("transaction not found")
+	ErrTransactionDetailNotFound = errors.New("transaction line not found")
+	ErrReturnExceedsSold         = errors.New("return quantity exceeds quantity sold")
+
+	ErrPromotionNotFound       = errors.New("promotion not found")
+	ErrPromotionTargetNotFound = errors.New("promotion target not found")
+	ErrPromoCodeTaken          = errors.New("promo code already used by another promotion")
+	ErrInvalidPromoCode        = errors.New("promo code is not valid")
+
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	ErrTaxRateInUse    = errors.New("tax rate is used by products or categories")
+
+	ErrPaymentInsufficient       = errors.New("payments do not cover the total")
+	ErrNonCashOverpayment        = errors.New("card and e-wallet payments cannot exceed the amount due")
+	ErrPaymentMethodNotSupported = errors.New("payment method is not supported")
+	ErrPaymentDeclined           = errors.New("payment declined")
+	ErrTransactionAlreadyPaid    = errors.New("transaction is already paid")
+	ErrTransactionNotPaid        = errors.New("transaction is not paid")
+	ErrTransactionCancelled      = errors.New("transaction is cancelled")
+	ErrPaymentPending            = errors.New("a payment of the transaction is still pending")
+	ErrPaymentNotFound           = errors.New("payment not found")
+	ErrPaymentAmountMismatch     = errors.New("paid amount does not match the payment")
+	ErrInvalidSignature          = errors.New("invalid callback signature")
+	ErrInvalidCallback           = errors.New("invalid callback")
+
+	ErrShiftAlreadyOpen = errors.New("you already have an open shift")
+	ErrNoOpenShift      = errors.New("open a shift first")
+
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrUsernameTaken      = errors.New("username already taken")
+)
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// LowStockProduct is a product whose stock is at or below its reorder
+// point.
+type LowStockProduct struct {
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	SKU          string `json:"sku"`
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Stock        int    `json:"stock"`
+	ReorderPoint int    `json:"reorder_point"`
+	ReorderQty   int    `json:"reorder_qty"`
+}
+
+// LowStockEvent is sent when a sale takes the stock of a product down to
+// or below its reorder point.
+type LowStockEvent struct {
+	Product       LowStockProduct `json:"product"`
+	TransactionID int             `json:"transaction_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor units of the store currency. Rupiah are
+// not divided into smaller units, so Rp15.000 is Money(15000). Money is a
+// plain integer in JSON and a BIGINT in the database, and all arithmetic on
+// it is integer arithmetic: anything that divides goes through MulRatio,
+// RoundTo or Allocate with an explicit RoundingMode.
+//
+// A store trades in a single currency, StoreCurrency, so Money does not
+// carry a currency code and amounts of different currencies never meet.
+// Transactions record the code they were made in as Currency, so sales
+// stay readable if the store currency is ever changed; other responses are
+// always in StoreCurrency.
+type Money int64
+
+// RoundingMode says how an amount that falls between two representable
+// values is rounded.
+type RoundingMode string
+
+const (
+	// RoundHalfUp rounds halves away from zero.
+	RoundHalfUp RoundingMode = "half_up"
+	// RoundHalfEven rounds halves to the even neighbour (banker's
+	// rounding), so rounding errors don't drift in one direction.
+	RoundHalfEven RoundingMode = "half_even"
+	// RoundDown truncates towards zero.
+	RoundDown RoundingMode = "down"
+)
+
+func (m RoundingMode) Valid() bool {
+	switch m {
+	case RoundHalfUp, RoundHalfEven, RoundDown:
+		return true
+	}
+	return false
+}
+
+// Currency describes the store currency. Exponent is the number of minor
+// unit digits, which is 0 for rupiah.
+type Currency struct {
+	Code               string
+	Symbol             string
+	Exponent           int
+	ThousandsSeparator string
+	DecimalSeparator   string
+}
+
+// IDR is the Indonesian rupiah, written like Rp15.000.
+var IDR = Currency{Code: "IDR", Symbol: "Rp", Exponent: 0, ThousandsSeparator: ".", DecimalSeparator: ","}
+
+// StoreCurrency is the currency all Money amounts are in. It is the only
+// currency the store trades in.
+var StoreCurrency = IDR
+
+// Times returns m multiplied by a quantity.
+func (m Money) Times(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// MulRatio returns m * num / den rounded with mode, e.g. a percentage with
+// den 100 or a tax rate in basis points with den 10000. den must not be
+// zero.
+func (m Money) MulRatio(num, den int64, mode RoundingMode) Money {
+	return Money(divide(int64(m)*num, den, mode))
+}
+
+// RoundTo rounds m to a multiple of unit with mode, e.g. to the nearest
+// Rp100 or Rp500 for cash payments. A unit of 0 or 1 leaves m unchanged.
+func (m Money) RoundTo(unit Money, mode RoundingMode) Money {
+	if unit <= 1 {
+		return m
+	}
+	return Money(divide(int64(m), int64(unit), mode)) * unit
+}
+
+// Allocate splits m across weights in proportion to them, so that the parts
+// add up to exactly m. Parts are rounded down and the minor units left over
+// go one each to the parts with the largest remainders, earlier parts first
+// on a tie. When m is no more than the total weight no part exceeds its
+// weight. Weights must not be negative; if they are all zero, every part is
+// zero.
+func (m Money) Allocate(weights []Money) []Money {
+	parts := make([]Money, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += int64(w)
+	}
+	if total == 0 {
+		return parts
+	}
+
+	remainders := make([]int64, len(weights))
+	left := m
+	for i, w := range weights {
+		parts[i] = Money(int64(m) * int64(w) / total)
+		remainders[i] = int64(m) * int64(w) % total
+		left -= parts[i]
+	}
+	for ; left > 0; left-- {
+		largest := -1
+		for i, r := range remainders {
+			if r > 0 && (largest < 0 || r > remainders[largest]) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			break
+		}
+		parts[largest]++
+		remainders[largest] = 0
+	}
+	return parts
+}
+
+// Format writes m in the notation of c, e.g. Rp15.000 or -Rp2.500.
+func (m Money) Format(c Currency) string {
+	sign := ""
+	amount := int64(m)
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= c.Exponent {
+		digits = strings.Repeat("0", c.Exponent-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-c.Exponent], digits[len(digits)-c.Exponent:]
+
+	var b strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(c.ThousandsSeparator)
+		}
+		b.WriteRune(d)
+	}
+	if fraction != "" {
+		b.WriteString(c.DecimalSeparator + fraction)
+	}
+	return fmt.Sprintf("%s%s%s", sign, c.Symbol, b.String())
+}
+
+func (m Money) String() string {
+	return m.Format(StoreCurrency)
+}
+
+// divide returns a / b rounded with mode.
+func divide(a, b int64, mode RoundingMode) int64 {
+	negative := (a < 0) != (b < 0)
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+
+	q, r := a/b, a%b
+	switch mode {
+	case RoundHalfUp:
+		if 2*r >= b {
+			q++
+		}
+	case RoundHalfEven:
+		if 2*r > b || (2*r == b && q%2 == 1) {
+			q++
+		}
+	}
+
+	if negative {
+		return -q
+	}
+	return q
+}
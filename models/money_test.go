@@ -0,0 +1,146 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMoneyMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		num, den int64
+		mode     RoundingMode
+		want     Money
+	}{
+		{"exact", 15000, 11, 100, RoundHalfUp, 1650},
+		{"below half up", 100, 1, 3, RoundHalfUp, 33},
+		{"below half even", 100, 1, 3, RoundHalfEven, 33},
+		{"below half down", 100, 1, 3, RoundDown, 33},
+		{"above half up", 200, 1, 3, RoundHalfUp, 67},
+		{"above half even", 200, 1, 3, RoundHalfEven, 67},
+		{"above half down", 200, 1, 3, RoundDown, 66},
+		{"tie to odd up", 2650, 1, 100, RoundHalfUp, 27},
+		{"tie to odd even", 2650, 1, 100, RoundHalfEven, 26},
+		{"tie to odd down", 2650, 1, 100, RoundDown, 26},
+		{"tie from odd up", 2750, 1, 100, RoundHalfUp, 28},
+		{"tie from odd even", 2750, 1, 100, RoundHalfEven, 28},
+		{"tie from odd down", 2750, 1, 100, RoundDown, 27},
+		{"basis points tie even", 125, 1000, 10000, RoundHalfEven, 12},
+		{"negative above half up", -200, 1, 3, RoundHalfUp, -67},
+		{"negative above half even", -200, 1, 3, RoundHalfEven, -67},
+		{"negative above half down", -200, 1, 3, RoundDown, -66},
+		{"negative tie up", -2650, 1, 100, RoundHalfUp, -27},
+		{"negative tie even", -2650, 1, 100, RoundHalfEven, -26},
+		{"negative tie from odd even", -2750, 1, 100, RoundHalfEven, -28},
+		{"negative tie down", -2750, 1, 100, RoundDown, -27},
+		{"negative denominator", 2650, 1, -100, RoundHalfUp, -27},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.MulRatio(tt.num, tt.den, tt.mode); got != tt.want {
+				t.Errorf("Money(%d).MulRatio(%d, %d, %s) = %d, want %d", tt.amount, tt.num, tt.den, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyRoundTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Money
+		unit   Money
+		mode   RoundingMode
+		want   Money
+	}{
+		{"Rp100 below half", 12345, 100, RoundHalfUp, 12300},
+		{"Rp100 above half", 12351, 100, RoundHalfEven, 12400},
+		{"Rp100 tie up", 12250, 100, RoundHalfUp, 12300},
+		{"Rp100 tie even", 12250, 100, RoundHalfEven, 12200},
+		{"Rp100 tie from odd even", 12350, 100, RoundHalfEven, 12400},
+		{"Rp100 down", 12399, 100, RoundDown, 12300},
+		{"Rp100 multiple", 12300, 100, RoundHalfUp, 12300},
+		{"Rp100 negative tie up", -12350, 100, RoundHalfUp, -12400},
+		{"Rp500 below half", 12749, 500, RoundHalfUp, 12500},
+		{"Rp500 tie up", 12250, 500, RoundHalfUp, 12500},
+		{"Rp500 tie even", 12250, 500, RoundHalfEven, 12000},
+		{"Rp500 tie from odd even", 12750, 500, RoundHalfEven, 13000},
+		{"Rp500 down", 12999, 500, RoundDown, 12500},
+		{"unit zero", 12345, 0, RoundHalfUp, 12345},
+		{"unit one", 12345, 1, RoundHalfUp, 12345},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.RoundTo(tt.unit, tt.mode); got != tt.want {
+				t.Errorf("Money(%d).RoundTo(%d, %s) = %d, want %d", tt.amount, tt.unit, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		weights []Money
+		want    []Money
+	}{
+		{"exact", 10, []Money{3, 3, 4}, []Money{3, 3, 4}},
+		{"largest remainders", 10, []Money{1, 2, 4}, []Money{1, 3, 6}},
+		{"tie goes to earlier part", 100, []Money{1, 1, 1}, []Money{34, 33, 33}},
+		{"discount across lines", 1000, []Money{15000, 25000, 10000}, []Money{300, 500, 200}},
+		{"zero weight gets nothing", 7, []Money{0, 1, 1}, []Money{0, 4, 3}},
+		{"all weights zero", 100, []Money{0, 0}, []Money{0, 0}},
+		{"no weights", 100, nil, []Money{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.amount.Allocate(tt.weights)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Money(%d).Allocate(%v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+			}
+			var sum Money
+			for _, part := range got {
+				sum += part
+			}
+			var weights Money
+			for _, w := range tt.weights {
+				weights += w
+			}
+			if weights > 0 && sum != tt.amount {
+				t.Errorf("parts add up to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	usd := Currency{Code: "USD", Symbol: "$", Exponent: 2, ThousandsSeparator: ",", DecimalSeparator: "."}
+
+	tests := []struct {
+		name     string
+		amount   Money
+		currency Currency
+		want     string
+	}{
+		{"zero", 0, IDR, "Rp0"},
+		{"hundreds", 999, IDR, "Rp999"},
+		{"thousands", 15000, IDR, "Rp15.000"},
+		{"millions", 1234567, IDR, "Rp1.234.567"},
+		{"negative", -2500, IDR, "-Rp2.500"},
+		{"minor units", 123456, usd, "$1,234.56"},
+		{"only minor units", 5, usd, "$0.05"},
+		{"negative minor units", -99, usd, "-$0.99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.Format(tt.currency); got != tt.want {
+				t.Errorf("Money(%d).Format(%s) = %q, want %q", tt.amount, tt.currency.Code, got, tt.want)
+			}
+		})
+	}
+}
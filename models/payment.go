@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+type PaymentMethod string
+
+const (
+	PaymentCash       PaymentMethod = "cash"
+	PaymentDebitCard  PaymentMethod = "debit_card"
+	PaymentCreditCard PaymentMethod = "credit_card"
+	PaymentEWallet    PaymentMethod = "e_wallet"
+	// PaymentQRIS is paid by the customer scanning a dynamic QRIS code and
+	// confirmed later by the gateway, so it is not a tender at checkout.
+	PaymentQRIS PaymentMethod = "qris"
+)
+
+// Valid reports whether m can be tendered at checkout.
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PaymentCash, PaymentDebitCard, PaymentCreditCard, PaymentEWallet:
+		return true
+	}
+	return false
+}
+
+// PaymentStatus is the payment state of a transaction (unpaid, paid or
+// cancelled) or of one of its payments (pending, paid, failed or expired).
+type PaymentStatus string
+
+const (
+	PaymentStatusUnpaid  PaymentStatus = "unpaid"
+	PaymentStatusPending PaymentStatus = "pending"
+	PaymentStatusPaid    PaymentStatus = "paid"
+	PaymentStatusFailed  PaymentStatus = "failed"
+	PaymentStatusExpired PaymentStatus = "expired"
+	// PaymentStatusCancelled is an unpaid transaction that was called off
+	// and its stock put back.
+	PaymentStatusCancelled PaymentStatus = "cancelled"
+)
+
+// Payment is one tender of a sale. Amount is what was tendered, so a cash
+// payment can exceed what was due and the difference is the transaction's
+// Change. Reference is the provider's reference, e.g. an EDC approval code.
+// A QRIS payment is pending until the gateway confirms it, and carries the
+// QR payload to show the customer until ExpiresAt. ShiftID is the shift
+// whose drawer took a payment made at the counter.
+type Payment struct {
+	ID            int           `json:"id"`
+	TransactionID int           `json:"transaction_id"`
+	Method        PaymentMethod `json:"method"`
+	Amount        Money         `json:"amount"`
+	Reference     string        `json:"reference"`
+	Status        PaymentStatus `json:"status"`
+	ShiftID       *int          `json:"shift_id,omitempty"`
+	QRPayload     string        `json:"qr_payload,omitempty"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	Method    PaymentMethod `json:"method"`
+	Amount    Money         `json:"amount"`
+	Reference string        `json:"reference,omitempty"`
+}
+
+// PayRequest pays an unpaid transaction.
+type PayRequest struct {
+	Payments []PaymentRequest `json:"payments"`
+}
@@ -1,10 +1,49 @@
 package models
 
+import "time"
+
 type Product struct {
 	ID           int    `json:"id"`
 	Name         string `json:"name"`
-	Price        int    `json:"price"`
+	SKU          string `json:"sku"`
+	Price        Money  `json:"price"`
 	Stock        int    `json:"stock"`
 	CategoryID   int    `json:"category_id"`
 	CategoryName string `json:"category_name"`
+	// TaxRateID is the tax rate of the product. Without one the product is
+	// taxed at the rate of its category.
+	TaxRateID *int `json:"tax_rate_id"`
+	// The product is low on stock once its stock is at or below
+	// ReorderPoint, and ReorderQty is how many to order then. A zero
+	// ReorderPoint turns low-stock alerts off.
+	ReorderPoint int `json:"reorder_point"`
+	ReorderQty   int `json:"reorder_qty"`
+	// Barcodes left out of an update request keep their current values;
+	// an empty list removes them all.
+	Barcodes []string `json:"barcodes"`
+	// Options are the option types the variants of the product are made
+	// of, e.g. ["Size", "Color"]. Like Barcodes, they are kept when left out
+	// of an update.
+	Options []string `json:"options"`
+	// Variants are only filled in when a single product is fetched.
+	Variants  []ProductVariant `json:"variants,omitempty"`
+	DeletedAt *time.Time       `json:"deleted_at,omitempty"`
+}
+
+// ProductFilter narrows and orders the product list. Nil and zero fields
+// are ignored.
+type ProductFilter struct {
+	Query      string
+	CategoryID int
+	// IncludeSubcategories widens CategoryID to all of its descendants.
+	IncludeSubcategories bool
+	MinPrice             *Money
+	MaxPrice             *Money
+	InStock              *bool
+	Sort                 string
+	IncludeDeleted       bool
 }
+
+// ProductSortOptions are the accepted values of ProductFilter.Sort. A
+// leading "-" sorts descending.
+var ProductSortOptions = []string{"name", "-name", "price", "-price", "stock", "-stock"}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type CostSource string
+
+const (
+	CostSourcePurchase CostSource = "purchase"
+	CostSourceManual   CostSource = "manual"
+)
+
+// ProductCost is an entry in the cost price history of a product. The
+// latest entry is the product's current cost; products with variants are
+// costed per variant, and the latest entry of each variant is its current
+// cost. Quantity is the number of
+// units bought at UnitCost, which weighs the entry in weighted average
+// costing; manual entries without one only set the last cost.
+type ProductCost struct {
+	ID              int        `json:"id"`
+	ProductID       int        `json:"product_id"`
+	VariantID       *int       `json:"variant_id,omitempty"`
+	UnitCost        Money      `json:"unit_cost"`
+	Quantity        int        `json:"quantity"`
+	Source          CostSource `json:"source"`
+	PurchaseOrderID *int       `json:"purchase_order_id,omitempty"`
+	Note            string     `json:"note"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ProductCostRequest sets the cost of a product, or of one of its variants
+// when it has them.
+type ProductCostRequest struct {
+	VariantID *int   `json:"variant_id,omitempty"`
+	UnitCost  Money  `json:"unit_cost"`
+	Quantity  int    `json:"quantity,omitempty"`
+	Note      string `json:"note"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// ProductVariant is a sellable version of a product, such as the large size
+// of a drink. Options holds one value for each of the product's option
+// types, e.g. {"Size": "Large"}.
+type ProductVariant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	Name      string            `json:"name"`
+	SKU       string            `json:"sku"`
+	Price     Money             `json:"price"`
+	Stock     int               `json:"stock"`
+	Options   map[string]string `json:"options"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+type PromotionType string
+
+const (
+	// PromotionPercentage takes Percent percent off each targeted line.
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixedAmount takes Amount off the targeted lines together,
+	// shared out in proportion to their amounts.
+	PromotionFixedAmount PromotionType = "fixed_amount"
+	// PromotionBuyXGetY makes FreeQuantity units free for every
+	// BuyQuantity + FreeQuantity units of a targeted line.
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+type PromotionTarget string
+
+const (
+	PromotionTargetAll      PromotionTarget = "all"
+	PromotionTargetProduct  PromotionTarget = "product"
+	PromotionTargetCategory PromotionTarget = "category"
+)
+
+func (t PromotionTarget) Valid() bool {
+	switch t {
+	case PromotionTargetAll, PromotionTargetProduct, PromotionTargetCategory:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount rule applied at checkout. Promotions with a Code
+// are vouchers and only apply when the code is given at checkout; the rest
+// apply automatically while active and within their validity window.
+// Promotions are applied in descending Priority. A promotion that isn't
+// Stackable only applies to lines no other promotion has discounted yet,
+// and once applied keeps later promotions off those lines.
+type Promotion struct {
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	Type         PromotionType   `json:"type"`
+	Percent      int             `json:"percent,omitempty"`
+	Amount       Money           `json:"amount,omitempty"`
+	BuyQuantity  int             `json:"buy_quantity,omitempty"`
+	FreeQuantity int             `json:"free_quantity,omitempty"`
+	TargetType   PromotionTarget `json:"target_type"`
+	TargetID     *int            `json:"target_id"`
+	Code         string          `json:"code"`
+	Stackable    bool            `json:"stackable"`
+	Priority     int             `json:"priority"`
+	StartsAt     *time.Time      `json:"starts_at"`
+	EndsAt       *time.Time      `json:"ends_at"`
+	Active       bool            `json:"active"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// TransactionDiscount records how much one promotion took off one
+// transaction line.
+type TransactionDiscount struct {
+	ID                  int    `json:"id"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	PromotionID         *int   `json:"promotion_id"`
+	PromotionName       string `json:"promotion_name"`
+	Amount              Money  `json:"amount"`
+}
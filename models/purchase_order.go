@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderOrdered           PurchaseOrderStatus = "ordered"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	Status       PurchaseOrderStatus `json:"status"`
+	Note         string              `json:"note"`
+	TotalCost    Money               `json:"total_cost"`
+	CreatedAt    time.Time           `json:"created_at"`
+	OrderedAt    *time.Time          `json:"ordered_at"`
+	ReceivedAt   *time.Time          `json:"received_at"`
+	Items        []PurchaseOrderItem `json:"items"`
+}
+
+type PurchaseOrderItem struct {
+	ID               int    `json:"id"`
+	PurchaseOrderID  int    `json:"purchase_order_id"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	VariantID        *int   `json:"variant_id,omitempty"`
+	VariantName      string `json:"variant_name,omitempty"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity int    `json:"received_quantity"`
+	UnitCost         Money  `json:"unit_cost"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID int   `json:"product_id"`
+	VariantID *int  `json:"variant_id,omitempty"`
+	Quantity  int   `json:"quantity"`
+	UnitCost  Money `json:"unit_cost"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id"`
+	Note       string                     `json:"note"`
+	Items      []PurchaseOrderItemRequest `json:"items"`
+}
+
+// ReceiveItemRequest receives part or all of a PO line. UnitCost overrides
+// the ordered cost when the invoice differs.
+type ReceiveItemRequest struct {
+	PurchaseOrderItemID int    `json:"purchase_order_item_id"`
+	Quantity            int    `json:"quantity"`
+	UnitCost            *Money `json:"unit_cost"`
+}
+
+type ReceivePurchaseOrderRequest struct {
+	Items []ReceiveItemRequest `json:"items"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type ReceiptFormat string
+
+const (
+	// ReceiptText is plain UTF-8 text laid out for the paper width.
+	ReceiptText ReceiptFormat = "text"
+	// ReceiptESCPOS is the byte stream of ESC/POS commands thermal
+	// receipt printers take.
+	ReceiptESCPOS ReceiptFormat = "escpos"
+	// ReceiptPDF is a single page PDF the width of the paper.
+	ReceiptPDF ReceiptFormat = "pdf"
+)
+
+func (f ReceiptFormat) Valid() bool {
+	switch f {
+	case ReceiptText, ReceiptESCPOS, ReceiptPDF:
+		return true
+	}
+	return false
+}
+
+// PaperWidth is the width of a thermal receipt roll in millimetres.
+type PaperWidth int
+
+const (
+	Paper58mm PaperWidth = 58
+	Paper80mm PaperWidth = 80
+)
+
+func (p PaperWidth) Valid() bool {
+	return p == Paper58mm || p == Paper80mm
+}
+
+// Columns is the number of characters a line holds in the printer's
+// standard font: 32 on 58mm paper and 48 on 80mm paper.
+func (p PaperWidth) Columns() int {
+	if p == Paper80mm {
+		return 48
+	}
+	return 32
+}
+
+// StoreInfo is printed at the top and bottom of receipts. Times are
+// printed in Location.
+type StoreInfo struct {
+	Name     string
+	Address  string
+	Phone    string
+	Footer   string
+	Location *time.Location
+}
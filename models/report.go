@@ -0,0 +1,215 @@
+package models
+
+import "time"
+
+type SalesGroupBy string
+
+const (
+	SalesByDay           SalesGroupBy = "day"
+	SalesByHour          SalesGroupBy = "hour"
+	SalesByCashier       SalesGroupBy = "cashier"
+	SalesByPaymentMethod SalesGroupBy = "payment_method"
+)
+
+func (g SalesGroupBy) Valid() bool {
+	switch g {
+	case SalesByDay, SalesByHour, SalesByCashier, SalesByPaymentMethod:
+		return true
+	}
+	return false
+}
+
+// SalesReportRequest asks for the paid sales from the start of From to the
+// end of To, both dates in the store timezone.
+type SalesReportRequest struct {
+	From    time.Time
+	To      time.Time
+	GroupBy SalesGroupBy
+}
+
+// SalesReportRow is the sales of one group. Group is the day (2006-01-02)
+// or hour (2006-01-02T15:00) in the store timezone, the cashier's username
+// or the payment method. Revenue is what the sales took; Refunds is what
+// has been refunded for returns of them so far, whenever they were
+// returned, so net revenue is Revenue minus Refunds. Grouped by payment
+// method, only Revenue and Refunds are reported: what was paid with the
+// method net of change, including cash rounding, and what was refunded by
+// it. A sale split across tenders can't be counted under one method, so
+// Transactions, ItemsSold, Discounts and Tax are left out.
+type SalesReportRow struct {
+	Group        string `json:"group"`
+	CashierID    *int   `json:"cashier_id,omitempty"`
+	Revenue      Money  `json:"revenue"`
+	Refunds      Money  `json:"refunds"`
+	Transactions *int   `json:"transactions,omitempty"`
+	ItemsSold    *int   `json:"items_sold,omitempty"`
+	Discounts    *Money `json:"discounts,omitempty"`
+	Tax          *Money `json:"tax,omitempty"`
+}
+
+// SalesReportTotals sums up all sales in a report, regardless of grouping.
+type SalesReportTotals struct {
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	Timezone     string       `json:"timezone"`
+	GroupBy      SalesGroupBy `json:"group_by"`
+	Revenue      Money        `json:"revenue"`
+	Refunds      Money        `json:"refunds"`
+	Transactions int          `json:"transactions"`
+	ItemsSold    int          `json:"items_sold"`
+	Discounts    Money        `json:"discounts"`
+	Tax          Money        `json:"tax"`
+}
+
+// ProductRanking is what top products are ranked by.
+type ProductRanking string
+
+const (
+	RankByQuantity ProductRanking = "quantity"
+	RankByRevenue  ProductRanking = "revenue"
+)
+
+func (r ProductRanking) Valid() bool {
+	return r == RankByQuantity || r == RankByRevenue
+}
+
+// TopProductsRequest asks for the Limit best selling products of the paid
+// sales from the start of From to the end of To. A CategoryID narrows them
+// to the category and its subcategories, and PerCategory ranks the
+// products of each category separately.
+type TopProductsRequest struct {
+	From        time.Time
+	To          time.Time
+	By          ProductRanking
+	Limit       int
+	CategoryID  int
+	PerCategory bool
+}
+
+// TopProduct is the sales of a product over a period. Revenue is net of
+// discounts and excludes tax and service charge. Returns are not taken off.
+type TopProduct struct {
+	Rank         int    `json:"rank"`
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	SKU          string `json:"sku"`
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Quantity     int    `json:"quantity"`
+	Revenue      Money  `json:"revenue"`
+	Transactions int    `json:"transactions"`
+}
+
+type TopProductsMeta struct {
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Timezone    string         `json:"timezone"`
+	By          ProductRanking `json:"by"`
+	Limit       int            `json:"limit"`
+	PerCategory bool           `json:"per_category"`
+}
+
+// SlowMoversRequest asks for the products not sold in the last Days days,
+// optionally in a category and its subcategories.
+type SlowMoversRequest struct {
+	Days       int
+	CategoryID int
+}
+
+// SlowMover is a product without sales since a cut-off, with its current
+// stock and that stock's value at the selling price. LastSoldAt is nil for
+// products that were never sold.
+type SlowMover struct {
+	ProductID    int        `json:"product_id"`
+	ProductName  string     `json:"product_name"`
+	SKU          string     `json:"sku"`
+	CategoryID   int        `json:"category_id"`
+	CategoryName string     `json:"category_name"`
+	Stock        int        `json:"stock"`
+	StockValue   Money      `json:"stock_value"`
+	LastSoldAt   *time.Time `json:"last_sold_at"`
+}
+
+// CostMethod is how the cost of goods sold is worked out from the cost
+// history of a product.
+type CostMethod string
+
+const (
+	// CostLast costs a sale at the latest cost recorded before it. A sale
+	// made before its product had a cost is uncosted.
+	CostLast CostMethod = "last"
+	// CostWeightedAverage costs a sale at the cumulative purchase average:
+	// the average of all costs recorded for the product before the sale,
+	// weighted by their quantities, whether or not that stock is still on
+	// hand. It falls back to CostLast when none of them has a quantity.
+	CostWeightedAverage CostMethod = "weighted_average"
+)
+
+func (m CostMethod) Valid() bool {
+	return m == CostLast || m == CostWeightedAverage
+}
+
+type MarginGroupBy string
+
+const (
+	MarginByProduct  MarginGroupBy = "product"
+	MarginByCategory MarginGroupBy = "category"
+	MarginByDay      MarginGroupBy = "day"
+	MarginByMonth    MarginGroupBy = "month"
+)
+
+func (g MarginGroupBy) Valid() bool {
+	switch g {
+	case MarginByProduct, MarginByCategory, MarginByDay, MarginByMonth:
+		return true
+	}
+	return false
+}
+
+// MarginReportRequest asks for the gross margin of the paid sales from the
+// start of From to the end of To, optionally of a category and its
+// subcategories.
+type MarginReportRequest struct {
+	From       time.Time
+	To         time.Time
+	GroupBy    MarginGroupBy
+	Method     CostMethod
+	CategoryID int
+}
+
+// MarginReportRow is the gross margin of one group: a product, a category,
+// a day (2006-01-02) or a month (2006-01) in the store timezone. Revenue is
+// net of discounts and excludes tax and service charge. Returned units and
+// their refunds are taken off Quantity and Revenue. Sales made before
+// their product had any recorded cost are uncosted: their quantity and
+// revenue are counted apart and left out of GrossProfit and Margin. Margin
+// is GrossProfit over costed revenue in basis points, nil without costed
+// revenue.
+type MarginReportRow struct {
+	Group            string `json:"group"`
+	ProductID        *int   `json:"product_id,omitempty"`
+	CategoryID       *int   `json:"category_id,omitempty"`
+	Quantity         int    `json:"quantity"`
+	Revenue          Money  `json:"revenue"`
+	COGS             Money  `json:"cogs"`
+	GrossProfit      Money  `json:"gross_profit"`
+	Margin           *int   `json:"margin"`
+	UncostedQuantity int    `json:"uncosted_quantity"`
+	UncostedRevenue  Money  `json:"uncosted_revenue"`
+}
+
+// MarginReportTotals sums up all rows of a margin report.
+type MarginReportTotals struct {
+	From             string        `json:"from"`
+	To               string        `json:"to"`
+	Timezone         string        `json:"timezone"`
+	GroupBy          MarginGroupBy `json:"group_by"`
+	Method           CostMethod    `json:"method"`
+	Quantity         int           `json:"quantity"`
+	Revenue          Money         `json:"revenue"`
+	COGS             Money         `json:"cogs"`
+	GrossProfit      Money         `json:"gross_profit"`
+	Margin           *int          `json:"margin"`
+	UncostedQuantity int           `json:"uncosted_quantity"`
+	UncostedRevenue  Money         `json:"uncosted_revenue"`
+}
@@ -0,0 +1,70 @@
+package models
+
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleManager Role = "manager"
+	RoleCashier Role = "cashier"
+)
+
+type Permission string
+
+const (
+	PermProductRead     Permission = "products:read"
+	PermProductWrite    Permission = "products:write"
+	PermCategoryRead    Permission = "categories:read"
+	PermCategoryWrite   Permission = "categories:write"
+	PermSaleCreate      Permission = "sales:create"
+	PermSaleRead        Permission = "sales:read"
+	PermSaleReturn      Permission = "sales:return"
+	PermInventoryManage Permission = "inventory:manage"
+	PermPurchaseManage  Permission = "purchases:manage"
+	PermPromotionManage Permission = "promotions:manage"
+	PermTaxManage       Permission = "taxes:manage"
+	PermShiftRead       Permission = "shifts:read"
+	PermReportRead      Permission = "reports:read"
+	PermUserManage      Permission = "users:manage"
+)
+
+// rolePermissions is the policy table consulted by the authorization
+// middleware. Cashiers can sell and look things up but can't edit the
+// catalogue or prices.
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermProductRead, PermProductWrite,
+		PermCategoryRead, PermCategoryWrite,
+		PermSaleCreate, PermSaleRead, PermSaleReturn,
+		PermInventoryManage, PermPurchaseManage,
+		PermPromotionManage, PermTaxManage,
+		PermShiftRead, PermReportRead,
+		PermUserManage,
+	},
+	RoleManager: {
+		PermProductRead, PermProductWrite,
+		PermCategoryRead, PermCategoryWrite,
+		PermSaleCreate, PermSaleRead, PermSaleReturn,
+		PermInventoryManage, PermPurchaseManage,
+		PermPromotionManage, PermTaxManage,
+		PermShiftRead, PermReportRead,
+	},
+	RoleCashier: {
+		PermProductRead,
+		PermCategoryRead,
+		PermSaleCreate, PermSaleRead,
+	},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// SalesReturn is a return against a sale. The refund is paid out by
+// RefundMethod; a cash refund is taken from the drawer of ShiftID, the shift
+// of the user who recorded it.
+type SalesReturn struct {
+	ID            int               `json:"id"`
+	TransactionID int               `json:"transaction_id"`
+	ShiftID       *int              `json:"shift_id"`
+	Reason        string            `json:"reason"`
+	RefundMethod  PaymentMethod     `json:"refund_method"`
+	RefundAmount  Money             `json:"refund_amount"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Items         []SalesReturnItem `json:"items"`
+}
+
+type SalesReturnItem struct {
+	ID                  int    `json:"id"`
+	ReturnID            int    `json:"return_id"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	ProductID           int    `json:"product_id"`
+	ProductName         string `json:"product_name"`
+	Quantity            int    `json:"quantity"`
+	RefundAmount        Money  `json:"refund_amount"`
+}
+
+type ReturnItemRequest struct {
+	TransactionDetailID int `json:"transaction_detail_id"`
+	Quantity            int `json:"quantity"`
+}
+
+// ReturnRequest is a return to record. RefundMethod defaults to cash.
+type ReturnRequest struct {
+	Reason       string              `json:"reason"`
+	RefundMethod PaymentMethod       `json:"refund_method"`
+	Items        []ReturnItemRequest `json:"items"`
+}
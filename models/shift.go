@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+type ShiftStatus string
+
+const (
+	ShiftOpen   ShiftStatus = "open"
+	ShiftClosed ShiftStatus = "closed"
+)
+
+// Shift is a cashier's session at the till, from opening the drawer with
+// OpeningCash to counting it at close. ExpectedCash is the opening cash plus
+// cash taken for sales net of change (CashSales), minus cash refunded for
+// returns (CashRefunds), plus CashIn minus CashOut.
+// While the shift is open these are worked out live; at close they are
+// stored with the CountedCash and the Variance between the two, which is
+// negative when the drawer is short.
+type Shift struct {
+	ID           int                 `json:"id"`
+	UserID       int                 `json:"user_id"`
+	Username     string              `json:"username"`
+	Status       ShiftStatus         `json:"status"`
+	OpeningCash  Money               `json:"opening_cash"`
+	CashSales    Money               `json:"cash_sales"`
+	CashRefunds  Money               `json:"cash_refunds"`
+	CashIn       Money               `json:"cash_in"`
+	CashOut      Money               `json:"cash_out"`
+	ExpectedCash Money               `json:"expected_cash"`
+	CountedCash  *Money              `json:"counted_cash"`
+	Variance     *Money              `json:"variance"`
+	Note         string              `json:"note"`
+	OpenedAt     time.Time           `json:"opened_at"`
+	ClosedAt     *time.Time          `json:"closed_at"`
+	Movements    []ShiftCashMovement `json:"movements,omitempty"`
+}
+
+type ShiftCashMovementType string
+
+const (
+	CashIn  ShiftCashMovementType = "cash_in"
+	CashOut ShiftCashMovementType = "cash_out"
+)
+
+func (t ShiftCashMovementType) Valid() bool {
+	return t == CashIn || t == CashOut
+}
+
+// ShiftCashMovement is cash put into or taken out of the drawer other than
+// for a sale or a return, such as a float top-up or a bank drop.
+type ShiftCashMovement struct {
+	ID        int                   `json:"id"`
+	ShiftID   int                   `json:"shift_id"`
+	Type      ShiftCashMovementType `json:"type"`
+	Amount    Money                 `json:"amount"`
+	Reason    string                `json:"reason"`
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+type OpenShiftRequest struct {
+	OpeningCash Money  `json:"opening_cash"`
+	Note        string `json:"note"`
+}
+
+type CashMovementRequest struct {
+	Type   ShiftCashMovementType `json:"type"`
+	Amount Money                 `json:"amount"`
+	Reason string                `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash Money  `json:"counted_cash"`
+	Note        string `json:"note"`
+}
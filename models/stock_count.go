@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type StockCountStatus string
+
+const (
+	StockCountOpen      StockCountStatus = "open"
+	StockCountApproved  StockCountStatus = "approved"
+	StockCountCancelled StockCountStatus = "cancelled"
+)
+
+// StockCount is a physical inventory (stock opname) session. While it is
+// open, manual stock edits of the products in its scope are rejected.
+type StockCount struct {
+	ID         int              `json:"id"`
+	Status     StockCountStatus `json:"status"`
+	CategoryID *int             `json:"category_id"`
+	Note       string           `json:"note"`
+	CreatedAt  time.Time        `json:"created_at"`
+	ClosedAt   *time.Time       `json:"closed_at"`
+	Items      []StockCountItem `json:"items"`
+}
+
+// StockCountItem is a counted product or variant. SystemStock is the stock
+// when it was counted, and approval adjusts the stock by the Variance from
+// it, so sales and receipts made in between are kept.
+type StockCountItem struct {
+	ProductID       int       `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	VariantID       *int      `json:"variant_id,omitempty"`
+	VariantName     string    `json:"variant_name,omitempty"`
+	CountedQuantity int       `json:"counted_quantity"`
+	SystemStock     int       `json:"system_stock"`
+	Variance        int       `json:"variance"`
+	CountedAt       time.Time `json:"counted_at"`
+}
+
+type OpenStockCountRequest struct {
+	CategoryID *int   `json:"category_id"`
+	Note       string `json:"note"`
+}
+
+// StockCountEntry is a counted quantity. Products with variants are
+// counted per variant.
+type StockCountEntry struct {
+	ProductID       int  `json:"product_id"`
+	VariantID       *int `json:"variant_id,omitempty"`
+	CountedQuantity int  `json:"counted_quantity"`
+}
+
+type SubmitStockCountRequest struct {
+	Items []StockCountEntry `json:"items"`
+}
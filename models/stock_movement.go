@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type StockMovementType string
+
+const (
+	StockMovementSale       StockMovementType = "sale"
+	StockMovementReturn     StockMovementType = "return"
+	StockMovementPurchase   StockMovementType = "purchase"
+	StockMovementAdjustment StockMovementType = "adjustment"
+	StockMovementTransfer   StockMovementType = "transfer"
+	// StockMovementCancellation puts back the stock of a cancelled sale.
+	StockMovementCancellation StockMovementType = "cancellation"
+)
+
+// StockMovement is one entry in the stock ledger. Quantity is signed:
+// positive movements add stock, negative ones remove it. For a variant,
+// StockAfter is the variant's stock.
+type StockMovement struct {
+	ID            int               `json:"id"`
+	ProductID     int               `json:"product_id"`
+	VariantID     *int              `json:"variant_id,omitempty"`
+	Type          StockMovementType `json:"type"`
+	Quantity      int               `json:"quantity"`
+	StockAfter    int               `json:"stock_after"`
+	ReferenceType string            `json:"reference_type,omitempty"`
+	ReferenceID   *int              `json:"reference_id,omitempty"`
+	Note          string            `json:"note"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+type StockAdjustmentRequest struct {
+	VariantID *int              `json:"variant_id,omitempty"`
+	Type      StockMovementType `json:"type"`
+	Quantity  int               `json:"quantity"`
+	Note      string            `json:"note"`
+}
+
+// StockDiscrepancy is a product whose cached stock doesn't match the sum of
+// its ledger entries.
+type StockDiscrepancy struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// TaxRate is a tax such as PPN. Rate is in basis points, so 1100 is 11%.
+// An inclusive rate is already part of the selling price; an exclusive one
+// is added on top of it at checkout. Products take the rate set on them or,
+// failing that, the one of their nearest category that has one.
+type TaxRate struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Rate      int    `json:"rate"`
+	Inclusive bool   `json:"inclusive"`
+}
+
+// TransactionTax is the tax of a transaction summed per tax rate.
+// TaxableAmount is the amount the tax was worked out on, excluding the tax
+// itself.
+type TransactionTax struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id"`
+	TaxRateID     *int   `json:"tax_rate_id"`
+	Name          string `json:"name"`
+	Rate          int    `json:"rate"`
+	Inclusive     bool   `json:"inclusive"`
+	TaxableAmount Money  `json:"taxable_amount"`
+	Amount        Money  `json:"amount"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Transaction is a completed sale. TotalAmount is the sum of the line
+// totals. Rounding is the cash rounding adjustment, only set when cash
+// settled the balance left by the other tenders. TaxTotal includes
+// inclusive taxes, which are already part of the prices, and Taxes breaks it
+// down per tax rate. All amounts are in Currency. A paid transaction's
+// Payments with status paid add up to TotalAmount plus Rounding plus
+// Change.
+type Transaction struct {
+	ID            int                 `json:"id"`
+	ShiftID       *int                `json:"shift_id"`
+	Currency      string              `json:"currency"`
+	Subtotal      Money               `json:"subtotal"`
+	DiscountTotal Money               `json:"discount_total"`
+	TaxTotal      Money               `json:"tax_total"`
+	ServiceCharge Money               `json:"service_charge"`
+	Rounding      Money               `json:"rounding"`
+	TotalAmount   Money               `json:"total_amount"`
+	PaymentStatus PaymentStatus       `json:"payment_status"`
+	Change        Money               `json:"change"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Details       []TransactionDetail `json:"details"`
+	Taxes         []TransactionTax    `json:"taxes"`
+	Payments      []Payment           `json:"payments"`
+}
+
+// TransactionDetail is a sold line. Subtotal is Price times Quantity before
+// discounts; Discount is the sum of Discounts, the promotions applied to it.
+// Tax and ServiceCharge are worked out on what is left after discounts, and
+// Total is what was paid for the line: Subtotal less Discount plus
+// ServiceCharge, plus Tax unless it is inclusive.
+type TransactionDetail struct {
+	ID            int                   `json:"id"`
+	TransactionID int                   `json:"transaction_id"`
+	ProductID     int                   `json:"product_id"`
+	ProductName   string                `json:"product_name"`
+	VariantID     *int                  `json:"variant_id,omitempty"`
+	VariantName   string                `json:"variant_name,omitempty"`
+	Quantity      int                   `json:"quantity"`
+	Price         Money                 `json:"price"`
+	Subtotal      Money                 `json:"subtotal"`
+	Discount      Money                 `json:"discount"`
+	Discounts     []TransactionDiscount `json:"discounts,omitempty"`
+	TaxRateID     *int                  `json:"tax_rate_id,omitempty"`
+	TaxName       string                `json:"tax_name,omitempty"`
+	TaxRate       int                   `json:"tax_rate"`
+	TaxInclusive  bool                  `json:"tax_inclusive"`
+	Tax           Money                 `json:"tax"`
+	ServiceCharge Money                 `json:"service_charge"`
+	Total         Money                 `json:"total"`
+}
+
+// CheckoutItem is a cart line. VariantID is required for products that
+// have variants and must be left out for those that don't.
+type CheckoutItem struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	Quantity  int  `json:"quantity"`
+}
+
+// CheckoutRequest is a cart. PromoCodes are voucher codes to redeem on top
+// of the automatic promotions. Payments, when given, must cover the total;
+// without them the transaction is recorded unpaid, to be paid afterwards.
+type CheckoutRequest struct {
+	Items      []CheckoutItem   `json:"items"`
+	PromoCodes []string         `json:"promo_codes,omitempty"`
+	Payments   []PaymentRequest `json:"payments,omitempty"`
+}
@@ -2,15 +2,22 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"kasir-api/models"
+	"slices"
+	"sort"
+	"time"
 )
 
 type CategoryRepository interface {
-	GetAll(limit, offset int) ([]models.Category, int, error)
-	GetByID(id int) (*models.Category, error)
+	GetAll(limit, offset int, includeDeleted bool) ([]models.Category, int, error)
+	GetAllUnpaged() ([]models.Category, error)
+	GetByID(id int, includeDeleted bool) (*models.Category, error)
 	Create(category models.Category) (models.Category, error)
 	Update(id int, category models.Category) (*models.Category, error)
-	Delete(id int) error
+	Delete(id int, reassignTo *int) error
+	Restore(id int) (*models.Category, error)
 }
 
 type categoryRepository struct {
@@ -21,14 +28,31 @@ func NewCategoryRepository(db *sql.DB) CategoryRepository {
 	return &categoryRepository{db}
 }
 
-func (r *categoryRepository) GetAll(limit, offset int) ([]models.Category, int, error) {
+// ensureCategoryActive returns ErrCategoryNotFound unless the category
+// exists and is not deleted.
+func ensureCategoryActive(tx *sql.Tx, id int) error {
+	var active bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&active)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fmt.Errorf("%w: id %d", models.ErrCategoryNotFound, id)
+	}
+	return nil
+}
+
+func (r *categoryRepository) GetAll(limit, offset int, includeDeleted bool) ([]models.Category, int, error) {
 	var total int
-	err := r.db.QueryRow("SELECT count(*) FROM categories").Scan(&total)
+	err := r.db.QueryRow("SELECT count(*) FROM categories WHERE $1 OR deleted_at IS NULL", includeDeleted).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	rows, err := r.db.Query("SELECT id, name FROM categories ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	rows, err := r.db.Query(`
+		SELECT id, name, parent_id, tax_rate_id, deleted_at FROM categories
+		WHERE $1 OR deleted_at IS NULL
+		ORDER BY id LIMIT $2 OFFSET $3`, includeDeleted, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -37,7 +61,7 @@ func (r *categoryRepository) GetAll(limit, offset int) ([]models.Category, int,
 	var categories []models.Category
 	for rows.Next() {
 		var c models.Category
-		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.TaxRateID, &c.DeletedAt); err != nil {
 			return nil, 0, err
 		}
 		categories = append(categories, c)
@@ -45,10 +69,33 @@ func (r *categoryRepository) GetAll(limit, offset int) ([]models.Category, int,
 	return categories, total, nil
 }
 
-func (r *categoryRepository) GetByID(id int) (*models.Category, error) {
+// GetAllUnpaged returns every category that is not deleted, ordered by
+// name, for building the category tree.
+func (r *categoryRepository) GetAllUnpaged() ([]models.Category, error) {
+	rows, err := r.db.Query("SELECT id, name, parent_id, tax_rate_id FROM categories WHERE deleted_at IS NULL ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.TaxRateID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// GetByID returns nil for a deleted category unless includeDeleted is set.
+func (r *categoryRepository) GetByID(id int, includeDeleted bool) (*models.Category, error) {
 	var c models.Category
-	err := r.db.QueryRow("SELECT id, name FROM categories WHERE id = $1", id).
-		Scan(&c.ID, &c.Name)
+	err := r.db.QueryRow(`
+		SELECT id, name, parent_id, tax_rate_id, deleted_at FROM categories
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)`, id, includeDeleted).
+		Scan(&c.ID, &c.Name, &c.ParentID, &c.TaxRateID, &c.DeletedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -58,22 +105,130 @@ func (r *categoryRepository) GetByID(id int) (*models.Category, error) {
 	return &c, nil
 }
 
+// lockCategoryTree serializes moves within the category tree for the rest
+// of tx, so that checking a move can't create a cycle and making it can't
+// interleave with another move.
+func lockCategoryTree(tx *sql.Tx) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('categories'))")
+	return err
+}
+
+// categoryAncestorIDs returns id together with the IDs of its parent,
+// grandparent and so on up to the root.
+func categoryAncestorIDs(tx *sql.Tx, id int) ([]int, error) {
+	// UNION rather than UNION ALL so the walk ends even if a cycle exists.
+	rows, err := tx.Query(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT id FROM ancestors`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var ancestorID int
+		if err := rows.Scan(&ancestorID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, ancestorID)
+	}
+	return ids, rows.Err()
+}
+
+// lockParentCategory returns ErrParentCategoryNotFound unless the category
+// exists and is not deleted, and keeps it from being deleted for the rest
+// of tx.
+func lockParentCategory(tx *sql.Tx, id int) error {
+	var locked int
+	err := tx.QueryRow("SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE", id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: id %d", models.ErrParentCategoryNotFound, id)
+	}
+	return err
+}
+
+// Create adds a category. Its parent is checked under the category tree
+// lock and held until the category is in place.
 func (r *categoryRepository) Create(category models.Category) (models.Category, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Category{}, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureTaxRateExists(tx, category.TaxRateID); err != nil {
+		return models.Category{}, err
+	}
+	if category.ParentID != nil {
+		if err := lockCategoryTree(tx); err != nil {
+			return models.Category{}, err
+		}
+		if err := lockParentCategory(tx, *category.ParentID); err != nil {
+			return models.Category{}, err
+		}
+	}
+
 	var id int
-	err := r.db.QueryRow(
-		"INSERT INTO categories (name) VALUES ($1) RETURNING id",
-		category.Name,
+	err = tx.QueryRow(
+		"INSERT INTO categories (name, parent_id, tax_rate_id) VALUES ($1, $2, $3) RETURNING id",
+		category.Name, category.ParentID, category.TaxRateID,
 	).Scan(&id)
 	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.Category{}, models.ErrParentCategoryNotFound
+		}
+		return models.Category{}, err
+	}
+	if err := tx.Commit(); err != nil {
 		return models.Category{}, err
 	}
 	category.ID = id
 	return category, nil
 }
 
+// Update changes a category. A new parent can't be the category itself or
+// one of its descendants, which would cut the subtree off from the root,
+// nor a deleted category; the checks and the move run under the category
+// tree lock.
 func (r *categoryRepository) Update(id int, category models.Category) (*models.Category, error) {
-	res, err := r.db.Exec("UPDATE categories SET name=$1 WHERE id=$2", category.Name, id)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureTaxRateExists(tx, category.TaxRateID); err != nil {
+		return nil, err
+	}
+	if category.ParentID != nil {
+		if err := lockCategoryTree(tx); err != nil {
+			return nil, err
+		}
+		if err := lockParentCategory(tx, *category.ParentID); err != nil {
+			return nil, err
+		}
+		ancestors, err := categoryAncestorIDs(tx, *category.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(ancestors, id) {
+			return nil, models.ErrCategoryCycle
+		}
+	}
+
+	res, err := tx.Exec("UPDATE categories SET name=$1, parent_id=$2, tax_rate_id=$3 WHERE id=$4 AND deleted_at IS NULL",
+		category.Name, category.ParentID, category.TaxRateID, id)
 	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrParentCategoryNotFound
+		}
 		return nil, err
 	}
 
@@ -84,11 +239,124 @@ func (r *categoryRepository) Update(id int, category models.Category) (*models.C
 	if count == 0 {
 		return nil, nil
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	category.ID = id
 	return &category, nil
 }
 
-func (r *categoryRepository) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM categories WHERE id=$1", id)
-	return err
+// Delete soft-deletes a category. Without reassignTo it refuses with a
+// CategoryInUseError while live products or subcategories still belong to
+// it; with reassignTo all of its products and subcategories, deleted ones
+// included, are moved there first, in the same transaction. The target
+// can't be the category itself or one of its subcategories, which are about
+// to lose their parent.
+func (r *categoryRepository) Delete(id int, reassignTo *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reassignTo != nil {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+		ancestors, err := categoryAncestorIDs(tx, *reassignTo)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(ancestors, id) {
+			return fmt.Errorf("%w: category %d is the deleted category or one of its subcategories",
+				models.ErrInvalidReassignTarget, *reassignTo)
+		}
+	}
+
+	// Lock both rows in ascending ID order so that two deletes reassigning
+	// to each other cannot deadlock.
+	locks := []int{id}
+	if reassignTo != nil {
+		locks = append(locks, *reassignTo)
+		sort.Ints(locks)
+	}
+	for _, lockID := range locks {
+		var locked int
+		err := tx.QueryRow("SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", lockID).Scan(&locked)
+		if err == sql.ErrNoRows {
+			if lockID == id {
+				return models.ErrCategoryNotFound
+			}
+			return fmt.Errorf("%w: category %d not found", models.ErrInvalidReassignTarget, lockID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if reassignTo != nil {
+		if _, err := tx.Exec("UPDATE products SET category_id = $1 WHERE category_id = $2", *reassignTo, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE categories SET parent_id = $1 WHERE parent_id = $2", *reassignTo, id); err != nil {
+			return err
+		}
+	}
+
+	var inUse models.CategoryInUseError
+	err = tx.QueryRow(`
+		SELECT (SELECT count(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT count(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL)`, id).
+		Scan(&inUse.Products, &inUse.Subcategories)
+	if err != nil {
+		return err
+	}
+	if inUse != (models.CategoryInUseError{}) {
+		return &inUse
+	}
+
+	if _, err := tx.Exec("UPDATE categories SET deleted_at = now() WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore brings back a deleted category; restoring a live one is a no-op.
+// Its parent must not be deleted.
+func (r *categoryRepository) Restore(id int) (*models.Category, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var parentID *int
+	var deletedAt *time.Time
+	err = tx.QueryRow("SELECT parent_id, deleted_at FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&parentID, &deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if deletedAt == nil {
+		return r.GetByID(id, false)
+	}
+
+	if parentID != nil {
+		if err := ensureCategoryActive(tx, *parentID); err != nil {
+			if errors.Is(err, models.ErrCategoryNotFound) {
+				return nil, fmt.Errorf("%w: restore parent category %d first", models.ErrCategoryDeleted, *parentID)
+			}
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE categories SET deleted_at = NULL WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id, false)
 }
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+type LowStockRepository interface {
+	GetAll() ([]models.LowStockProduct, error)
+	ClaimAlerts(productIDs []int) ([]models.LowStockProduct, error)
+	ReleaseAlert(productID int) error
+}
+
+type lowStockRepository struct {
+	db *sql.DB
+}
+
+func NewLowStockRepository(db *sql.DB) LowStockRepository {
+	return &lowStockRepository{db}
+}
+
+func scanLowStockProduct(row interface{ Scan(...any) error }) (models.LowStockProduct, error) {
+	var p models.LowStockProduct
+	err := row.Scan(&p.ProductID, &p.ProductName, &p.SKU, &p.CategoryID, &p.CategoryName, &p.Stock, &p.ReorderPoint, &p.ReorderQty)
+	return p, err
+}
+
+func collectLowStockProducts(rows *sql.Rows) ([]models.LowStockProduct, error) {
+	defer rows.Close()
+	products := make([]models.LowStockProduct, 0)
+	for rows.Next() {
+		p, err := scanLowStockProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// GetAll lists the products, not deleted, at or below their reorder point,
+// those with the least stock relative to it first.
+func (r *lowStockRepository) GetAll() ([]models.LowStockProduct, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.sku, ''), p.category_id, c.name, p.stock, p.reorder_point, p.reorder_qty
+		FROM products p
+		JOIN categories c ON p.category_id = c.id
+		WHERE p.deleted_at IS NULL AND p.reorder_point > 0 AND p.stock <= p.reorder_point
+		ORDER BY p.stock::numeric / p.reorder_point, p.name, p.id`)
+	if err != nil {
+		return nil, err
+	}
+	return collectLowStockProducts(rows)
+}
+
+// ClaimAlerts marks the products among productIDs that are low on stock
+// and haven't been alerted since they last were above their reorder point
+// as alerted, and returns them. Concurrent claims never return the same
+// product twice.
+func (r *lowStockRepository) ClaimAlerts(productIDs []int) ([]models.LowStockProduct, error) {
+	rows, err := r.db.Query(`
+		UPDATE products p SET low_stock_alerted = true
+		FROM categories c
+		WHERE p.category_id = c.id AND p.id = ANY($1::int[]) AND p.deleted_at IS NULL
+			AND p.reorder_point > 0 AND p.stock <= p.reorder_point AND NOT p.low_stock_alerted
+		RETURNING p.id, p.name, COALESCE(p.sku, ''), p.category_id, c.name, p.stock, p.reorder_point, p.reorder_qty`,
+		productIDs)
+	if err != nil {
+		return nil, err
+	}
+	return collectLowStockProducts(rows)
+}
+
+// ReleaseAlert clears the alerted mark of a product, e.g. when its alert
+// couldn't be delivered, so the next sale alerts again.
+func (r *lowStockRepository) ReleaseAlert(productID int) error {
+	_, err := r.db.Exec("UPDATE products SET low_stock_alerted = false WHERE id = $1", productID)
+	return err
+}
@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type PaymentRepository interface {
+	CreatePending(transactionID int, method models.PaymentMethod, charge ChargeFunc) (*models.Payment, error)
+	Complete(method models.PaymentMethod, reference string, status models.PaymentStatus, amount models.Money) (payment *models.Payment, overpaid bool, err error)
+}
+
+type paymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) PaymentRepository {
+	return &paymentRepository{db}
+}
+
+// ChargeFunc starts a charge for amount at the payment gateway, returning
+// the pending payment with its reference, QR payload and expiry.
+type ChargeFunc func(amount models.Money) (models.Payment, error)
+
+const paymentColumns = `
+	SELECT id, transaction_id, method, amount, reference, status, shift_id, qr_payload, expires_at, created_at
+	FROM transaction_payments`
+
+func scanPayment(row interface{ Scan(...any) error }) (models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.Reference, &p.Status, &p.ShiftID, &p.QRPayload, &p.ExpiresAt, &p.CreatedAt)
+	return p, err
+}
+
+// CreatePending returns the pending payment by method for the total of an
+// unpaid transaction. A pending payment that hasn't expired is returned as
+// is, so asking again shows the same QR code; otherwise charge starts a new
+// one. The transaction row is locked so two requests cannot both charge it.
+// It returns nil, nil if the transaction doesn't exist.
+func (r *paymentRepository) CreatePending(transactionID int, method models.PaymentMethod, charge ChargeFunc) (*models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var total models.Money
+	var status models.PaymentStatus
+	err = tx.QueryRow("SELECT total_amount, payment_status FROM transactions WHERE id = $1 FOR UPDATE", transactionID).
+		Scan(&total, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := ensureUnpaid(status, transactionID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transaction_payments SET status = 'expired'
+		WHERE transaction_id = $1 AND status = 'pending' AND expires_at <= now()`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := scanPayment(tx.QueryRow(paymentColumns+`
+		WHERE transaction_id = $1 AND method = $2 AND status = 'pending' AND amount = $3
+		ORDER BY id DESC LIMIT 1`, transactionID, method, total))
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	p, err = charge(total)
+	if err != nil {
+		return nil, err
+	}
+	p.TransactionID = transactionID
+	p.Method = method
+	p.Amount = total
+	p.Status = models.PaymentStatusPending
+	err = tx.QueryRow(`
+		INSERT INTO transaction_payments (transaction_id, method, amount, reference, status, qr_payload, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		p.TransactionID, p.Method, p.Amount, p.Reference, p.Status, p.QRPayload, p.ExpiresAt,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Complete records the gateway's final status for the pending payment with
+// the given reference. A paid payment marks its transaction paid, even when
+// it had already expired here. Payments that are no longer pending are
+// otherwise returned unchanged, so a repeated callback is harmless. overpaid
+// reports that the payment was confirmed for a transaction that had
+// meanwhile been paid another way or cancelled, and must be refunded.
+func (r *paymentRepository) Complete(method models.PaymentMethod, reference string, status models.PaymentStatus, amount models.Money) (*models.Payment, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Lock the transaction before the payment, in the same order as
+	// CreatePending and checkout, so concurrent callbacks cannot deadlock.
+	var transactionID int
+	err = tx.QueryRow("SELECT transaction_id FROM transaction_payments WHERE method = $1 AND reference = $2", method, reference).
+		Scan(&transactionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, fmt.Errorf("%w: %s", models.ErrPaymentNotFound, reference)
+		}
+		return nil, false, err
+	}
+
+	var transactionStatus models.PaymentStatus
+	err = tx.QueryRow("SELECT payment_status FROM transactions WHERE id = $1 FOR UPDATE", transactionID).
+		Scan(&transactionStatus)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p, err := scanPayment(tx.QueryRow(paymentColumns+" WHERE method = $1 AND reference = $2 FOR UPDATE", method, reference))
+	if err != nil {
+		return nil, false, err
+	}
+	changed, err := applyGatewayStatus(&p, status, amount)
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		return &p, false, nil
+	}
+	if _, err := tx.Exec("UPDATE transaction_payments SET status = $1 WHERE id = $2", status, p.ID); err != nil {
+		return nil, false, err
+	}
+
+	overpaid := false
+	if status == models.PaymentStatusPaid {
+		if transactionStatus != models.PaymentStatusUnpaid {
+			overpaid = true
+		} else {
+			_, err := tx.Exec("UPDATE transactions SET payment_status = 'paid', change_amount = 0 WHERE id = $1", transactionID)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return &p, overpaid, nil
+}
+
+// applyGatewayStatus sets the status a gateway reported for p and reports
+// whether it changed. A payment that is no longer pending is left as is, so
+// a repeated callback is a no-op, except that an expired payment can still
+// be reported paid: the customer scanned the code late and the money was
+// taken. A payment reported paid must have been paid in full.
+func applyGatewayStatus(p *models.Payment, status models.PaymentStatus, amount models.Money) (bool, error) {
+	latePaid := p.Status == models.PaymentStatusExpired && status == models.PaymentStatusPaid
+	if p.Status != models.PaymentStatusPending && !latePaid {
+		return false, nil
+	}
+	if status == models.PaymentStatusPaid && amount != p.Amount {
+		return false, fmt.Errorf("%w: expected %s, got %s", models.ErrPaymentAmountMismatch, p.Amount, amount)
+	}
+	p.Status = status
+	return true, nil
+}
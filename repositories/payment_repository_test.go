@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"errors"
+	"kasir-api/models"
+	"testing"
+)
+
+func TestApplyGatewayStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     models.PaymentStatus
+		status      models.PaymentStatus
+		amount      models.Money
+		wantChanged bool
+		wantStatus  models.PaymentStatus
+		wantErr     error
+	}{
+		{"paid", models.PaymentStatusPending, models.PaymentStatusPaid, 35000, true, models.PaymentStatusPaid, nil},
+		{"failed", models.PaymentStatusPending, models.PaymentStatusFailed, 0, true, models.PaymentStatusFailed, nil},
+		{"expired", models.PaymentStatusPending, models.PaymentStatusExpired, 0, true, models.PaymentStatusExpired, nil},
+		{"paid short", models.PaymentStatusPending, models.PaymentStatusPaid, 34000, false, models.PaymentStatusPending, models.ErrPaymentAmountMismatch},
+		{"paid over", models.PaymentStatusPending, models.PaymentStatusPaid, 36000, false, models.PaymentStatusPending, models.ErrPaymentAmountMismatch},
+		{"repeated paid", models.PaymentStatusPaid, models.PaymentStatusPaid, 35000, false, models.PaymentStatusPaid, nil},
+		{"repeated with other amount", models.PaymentStatusPaid, models.PaymentStatusPaid, 1, false, models.PaymentStatusPaid, nil},
+		{"failed after paid", models.PaymentStatusPaid, models.PaymentStatusFailed, 0, false, models.PaymentStatusPaid, nil},
+		{"paid after expired", models.PaymentStatusExpired, models.PaymentStatusPaid, 35000, true, models.PaymentStatusPaid, nil},
+		{"paid short after expired", models.PaymentStatusExpired, models.PaymentStatusPaid, 34000, false, models.PaymentStatusExpired, models.ErrPaymentAmountMismatch},
+		{"failed after expired", models.PaymentStatusExpired, models.PaymentStatusFailed, 0, false, models.PaymentStatusExpired, nil},
+		{"paid after failed", models.PaymentStatusFailed, models.PaymentStatusPaid, 35000, false, models.PaymentStatusFailed, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := models.Payment{Method: models.PaymentQRIS, Amount: 35000, Status: tt.current}
+			changed, err := applyGatewayStatus(&p, tt.status, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged || p.Status != tt.wantStatus {
+				t.Errorf("changed, status = %v, %s, want %v, %s", changed, p.Status, tt.wantChanged, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type ProductCostRepository interface {
+	GetByProductID(productID, limit, offset int) ([]models.ProductCost, int, error)
+	Create(cost models.ProductCost) (*models.ProductCost, error)
+}
+
+type productCostRepository struct {
+	db *sql.DB
+}
+
+func NewProductCostRepository(db *sql.DB) ProductCostRepository {
+	return &productCostRepository{db}
+}
+
+// recordCost appends c to the cost history of its product or variant,
+// filling in ID and CreatedAt.
+func recordCost(q interface {
+	QueryRow(string, ...any) *sql.Row
+}, c *models.ProductCost) error {
+	return q.QueryRow(`
+		INSERT INTO product_costs (product_id, variant_id, unit_cost, quantity, source, purchase_order_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		c.ProductID, c.VariantID, c.UnitCost, c.Quantity, c.Source, c.PurchaseOrderID, c.Note,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *productCostRepository) GetByProductID(productID, limit, offset int) ([]models.ProductCost, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM product_costs WHERE product_id = $1", productID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, product_id, variant_id, unit_cost, quantity, source, purchase_order_id, note, created_at
+		FROM product_costs
+		WHERE product_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	costs := make([]models.ProductCost, 0)
+	for rows.Next() {
+		var c models.ProductCost
+		if err := rows.Scan(&c.ID, &c.ProductID, &c.VariantID, &c.UnitCost, &c.Quantity, &c.Source, &c.PurchaseOrderID, &c.Note, &c.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		costs = append(costs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return costs, total, nil
+}
+
+// Create records a cost of a product, which must name one of its variants
+// when it has them.
+func (r *productCostRepository) Create(cost models.ProductCost) (*models.ProductCost, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", cost.ProductID).
+		Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: id %d", models.ErrProductNotFound, cost.ProductID)
+	}
+	if err := checkVariantChoice(tx, cost.ProductID, cost.VariantID); err != nil {
+		return nil, err
+	}
+
+	if err := recordCost(tx, &cost); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &cost, nil
+}
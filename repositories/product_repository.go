@@ -2,15 +2,21 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"kasir-api/models"
+	"strings"
+	"time"
 )
 
 type ProductRepository interface {
-	GetAll(limit, offset int) ([]models.Product, int, error)
-	GetByID(id int) (*models.Product, error)
+	GetAll(filter models.ProductFilter, limit, offset int) ([]models.Product, int, error)
+	GetByID(id int, includeDeleted bool) (*models.Product, error)
+	GetByBarcode(code string) (*models.Product, error)
 	Create(product models.Product) (models.Product, error)
 	Update(id int, product models.Product) (*models.Product, error)
 	Delete(id int) error
+	Restore(id int) (*models.Product, error)
 }
 
 type productRepository struct {
@@ -21,18 +27,113 @@ func NewProductRepository(db *sql.DB) ProductRepository {
 	return &productRepository{db}
 }
 
-func (r *productRepository) GetAll(limit, offset int) ([]models.Product, int, error) {
+const productColumns = `
+	SELECT p.id, p.name, COALESCE(p.sku, ''), p.price, p.stock, p.category_id, c.name, p.tax_rate_id,
+		p.reorder_point, p.reorder_qty,
+		COALESCE((SELECT string_agg(b.code, ',' ORDER BY b.code) FROM product_barcodes b WHERE b.product_id = p.id), ''),
+		COALESCE((SELECT string_agg(o.name, ',' ORDER BY o.position) FROM product_options o WHERE o.product_id = p.id), ''),
+		p.deleted_at
+	FROM products p
+	JOIN categories c ON p.category_id = c.id`
+
+func scanProduct(row interface{ Scan(...any) error }) (models.Product, error) {
+	var p models.Product
+	var barcodes, options string
+	if err := row.Scan(&p.ID, &p.Name, &p.SKU, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName, &p.TaxRateID,
+		&p.ReorderPoint, &p.ReorderQty, &barcodes, &options, &p.DeletedAt); err != nil {
+		return p, err
+	}
+	p.Barcodes = []string{}
+	if barcodes != "" {
+		p.Barcodes = strings.Split(barcodes, ",")
+	}
+	p.Options = []string{}
+	if options != "" {
+		p.Options = strings.Split(options, ",")
+	}
+	return p, nil
+}
+
+// productSortColumns maps ProductFilter.Sort fields to SQL columns. Only
+// these columns can ever reach the ORDER BY clause.
+var productSortColumns = map[string]string{
+	"name":  "p.name",
+	"price": "p.price",
+	"stock": "p.stock",
+}
+
+// productFilterClause builds a parameterized WHERE clause for filter.
+func productFilterClause(filter models.ProductFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "p.deleted_at IS NULL")
+	}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Query != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(filter.Query)
+		add("p.name ILIKE '%%' || $%d || '%%'", escaped)
+	}
+	if filter.CategoryID > 0 && filter.IncludeSubcategories {
+		add(`p.category_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id = $%d
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT id FROM subtree)`, filter.CategoryID)
+	} else if filter.CategoryID > 0 {
+		add("p.category_id = $%d", filter.CategoryID)
+	}
+	if filter.MinPrice != nil {
+		add("p.price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		add("p.price <= $%d", *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			conditions = append(conditions, "p.stock > 0")
+		} else {
+			conditions = append(conditions, "p.stock = 0")
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func productOrderClause(sort string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+	column, ok := productSortColumns[sort]
+	if !ok {
+		return " ORDER BY p.id"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, p.id", column, direction)
+}
+
+func (r *productRepository) GetAll(filter models.ProductFilter, limit, offset int) ([]models.Product, int, error) {
+	where, args := productFilterClause(filter)
+
 	var total int
-	err := r.db.QueryRow("SELECT count(*) FROM products").Scan(&total)
+	err := r.db.QueryRow("SELECT count(*) FROM products p"+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	rows, err := r.db.Query(`
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name
-		FROM products p
-		JOIN categories c ON p.category_id = c.id
-		ORDER BY p.id LIMIT $1 OFFSET $2`, limit, offset)
+	query := productColumns + where + productOrderClause(filter.Sort) +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := r.db.Query(query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -40,8 +141,8 @@ func (r *productRepository) GetAll(limit, offset int) ([]models.Product, int, er
 
 	var products []models.Product
 	for rows.Next() {
-		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName); err != nil {
+		p, err := scanProduct(rows)
+		if err != nil {
 			return nil, 0, err
 		}
 		products = append(products, p)
@@ -49,34 +150,130 @@ func (r *productRepository) GetAll(limit, offset int) ([]models.Product, int, er
 	return products, total, nil
 }
 
-func (r *productRepository) GetByID(id int) (*models.Product, error) {
-	var p models.Product
-	err := r.db.QueryRow(`
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name
-		FROM products p
-		JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1`, id).
-		Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName)
+// GetByID returns nil for a deleted product unless includeDeleted is set.
+func (r *productRepository) GetByID(id int, includeDeleted bool) (*models.Product, error) {
+	return r.getOne(productColumns+" WHERE p.id = $1 AND ($2 OR p.deleted_at IS NULL)", id, includeDeleted)
+}
+
+func (r *productRepository) GetByBarcode(code string) (*models.Product, error) {
+	return r.getOne(productColumns+`
+		WHERE p.id = (SELECT product_id FROM product_barcodes WHERE code = $1) AND p.deleted_at IS NULL`, code)
+}
+
+func (r *productRepository) getOne(query string, args ...interface{}) (*models.Product, error) {
+	p, err := scanProduct(r.db.QueryRow(query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+
+	p.Variants, err = variantsOf(r.db, p.ID)
+	if err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
+// setBarcodes replaces the barcodes of a product.
+func setBarcodes(tx *sql.Tx, productID int, barcodes []string) error {
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+	for _, code := range barcodes {
+		_, err := tx.Exec("INSERT INTO product_barcodes (code, product_id) VALUES ($1, $2)", code, productID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %s", models.ErrBarcodeTaken, code)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// setOptions replaces the option types of a product. They can't change
+// once the product has variants, as the variants are made of them.
+func setOptions(tx *sql.Tx, productID int, options []string) error {
+	var current string
+	var hasVariants bool
+	err := tx.QueryRow(`
+		SELECT COALESCE((SELECT string_agg(name, ',' ORDER BY position) FROM product_options WHERE product_id = $1), ''),
+			EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)`, productID).Scan(&current, &hasVariants)
+	if err != nil {
+		return err
+	}
+	if current == strings.Join(options, ",") {
+		return nil
+	}
+	if hasVariants {
+		return models.ErrProductHasVariants
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_options WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+	for i, name := range options {
+		_, err := tx.Exec("INSERT INTO product_options (product_id, name, position) VALUES ($1, $2, $3)", productID, name, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *productRepository) Create(product models.Product) (models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Product{}, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureCategoryActive(tx, product.CategoryID); err != nil {
+		return models.Product{}, err
+	}
+	if err := ensureTaxRateExists(tx, product.TaxRateID); err != nil {
+		return models.Product{}, err
+	}
+
 	var id int
-	err := r.db.QueryRow(
-		"INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id",
-		product.Name, product.Price, product.Stock, product.CategoryID,
+	err = tx.QueryRow(
+		`INSERT INTO products (name, sku, price, stock, category_id, tax_rate_id, reorder_point, reorder_qty)
+		VALUES ($1, NULLIF($2, ''), $3, 0, $4, $5, $6, $7) RETURNING id`,
+		product.Name, product.SKU, product.Price, product.CategoryID, product.TaxRateID, product.ReorderPoint, product.ReorderQty,
 	).Scan(&id)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return models.Product{}, models.ErrSKUTaken
+		}
+		return models.Product{}, err
+	}
+
+	if err := setBarcodes(tx, id, product.Barcodes); err != nil {
+		return models.Product{}, err
+	}
+	if err := setOptions(tx, id, product.Options); err != nil {
+		return models.Product{}, err
+	}
+
+	if product.Stock != 0 {
+		err := moveStock(tx, &models.StockMovement{
+			ProductID: id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  product.Stock,
+			Note:      "Initial stock",
+		})
+		if err != nil {
+			return models.Product{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
 		return models.Product{}, err
 	}
 
-	createdProduct, err := r.GetByID(id)
+	createdProduct, err := r.GetByID(id, false)
 	if err != nil {
 		return models.Product{}, err
 	}
@@ -87,25 +284,128 @@ func (r *productRepository) Create(product models.Product) (models.Product, erro
 	return *createdProduct, nil
 }
 
+// Update changes the product's details. A different stock value is not
+// written directly; the difference is booked as an adjustment movement,
+// which moveStock refuses for products with variants.
 func (r *productRepository) Update(id int, product models.Product) (*models.Product, error) {
-	res, err := r.db.Exec("UPDATE products SET name=$1, price=$2, stock=$3, category_id=$4 WHERE id=$5",
-		product.Name, product.Price, product.Stock, product.CategoryID, id)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	count, err := res.RowsAffected()
+	var stock int
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&stock)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
-	if count == 0 {
-		return nil, nil
+	if err := ensureCategoryActive(tx, product.CategoryID); err != nil {
+		return nil, err
+	}
+	if err := ensureTaxRateExists(tx, product.TaxRateID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET name=$1, sku=NULLIF($2, ''), price=$3, category_id=$4, tax_rate_id=$5,
+			reorder_point=$6, reorder_qty=$7, low_stock_alerted = low_stock_alerted AND stock <= $6
+		WHERE id=$8`,
+		product.Name, product.SKU, product.Price, product.CategoryID, product.TaxRateID, product.ReorderPoint, product.ReorderQty, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrSKUTaken
+		}
+		return nil, err
+	}
+
+	if product.Barcodes != nil {
+		if err := setBarcodes(tx, id, product.Barcodes); err != nil {
+			return nil, err
+		}
+	}
+	if product.Options != nil {
+		if err := setOptions(tx, id, product.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	if delta := product.Stock - stock; delta != 0 {
+		if err := ensureNotCounting(tx, id); err != nil {
+			return nil, err
+		}
+		err := moveStock(tx, &models.StockMovement{
+			ProductID: id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  delta,
+			Note:      "Manual stock edit",
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return r.GetByID(id)
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id, false)
 }
 
+// Delete soft-deletes a product: it disappears from the catalogue and
+// can't be sold any more, but past sales, returns and stock movements keep
+// pointing at it.
 func (r *productRepository) Delete(id int) error {
-	_, err := r.db.Exec("DELETE FROM products WHERE id=$1", id)
-	return err
+	res, err := r.db.Exec("UPDATE products SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrProductNotFound
+	}
+	return nil
+}
+
+// Restore brings back a deleted product; restoring a live one is a no-op.
+// Its category must not be deleted.
+func (r *productRepository) Restore(id int) (*models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var categoryID int
+	var deletedAt *time.Time
+	err = tx.QueryRow("SELECT category_id, deleted_at FROM products WHERE id = $1 FOR UPDATE", id).Scan(&categoryID, &deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if deletedAt == nil {
+		return r.GetByID(id, false)
+	}
+
+	if err := ensureCategoryActive(tx, categoryID); err != nil {
+		if errors.Is(err, models.ErrCategoryNotFound) {
+			return nil, fmt.Errorf("%w: restore category %d first", models.ErrCategoryDeleted, categoryID)
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE products SET deleted_at = NULL WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id, false)
 }
@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"kasir-api/models"
+	"slices"
+	"strings"
+)
+
+type ProductVariantRepository interface {
+	GetByProductID(productID int) ([]models.ProductVariant, error)
+	Create(variant models.ProductVariant) (*models.ProductVariant, error)
+	Update(productID, id int, variant models.ProductVariant) (*models.ProductVariant, error)
+	Delete(productID, id int) error
+}
+
+type productVariantRepository struct {
+	db *sql.DB
+}
+
+func NewProductVariantRepository(db *sql.DB) ProductVariantRepository {
+	return &productVariantRepository{db}
+}
+
+const productVariantColumns = `
+	SELECT id, product_id, name, COALESCE(sku, ''), price, stock, options
+	FROM product_variants`
+
+func scanProductVariant(row interface{ Scan(...any) error }) (models.ProductVariant, error) {
+	var v models.ProductVariant
+	var options []byte
+	if err := row.Scan(&v.ID, &v.ProductID, &v.Name, &v.SKU, &v.Price, &v.Stock, &options); err != nil {
+		return v, err
+	}
+	return v, json.Unmarshal(options, &v.Options)
+}
+
+// variantsOf lists the variants of a product in creation order.
+func variantsOf(db *sql.DB, productID int) ([]models.ProductVariant, error) {
+	rows, err := db.Query(productVariantColumns+" WHERE product_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []models.ProductVariant{}
+	for rows.Next() {
+		v, err := scanProductVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+func (r *productVariantRepository) GetByProductID(productID int) ([]models.ProductVariant, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrProductNotFound
+	}
+	return variantsOf(r.db, productID)
+}
+
+func (r *productVariantRepository) getByID(productID, id int) (*models.ProductVariant, error) {
+	v, err := scanProductVariant(r.db.QueryRow(productVariantColumns+" WHERE id = $1 AND product_id = $2", id, productID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// lockVariant locks a product and then one of its variants, the same order
+// checkout and moveStock use, and returns the variant's stock.
+func lockVariant(tx *sql.Tx, productID, id int) (int, error) {
+	if _, err := tx.Exec("SELECT 1 FROM products WHERE id = $1 FOR UPDATE", productID); err != nil {
+		return 0, err
+	}
+	var stock int
+	err := tx.QueryRow("SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE", id, productID).Scan(&stock)
+	return stock, err
+}
+
+// checkVariantChoice verifies that variantID names a variant of the product
+// when the product has variants, and is nil when it has none.
+func checkVariantChoice(tx *sql.Tx, productID int, variantID *int) error {
+	var hasVariants, found bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1),
+			EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND id = $2)`,
+		productID, variantID).Scan(&hasVariants, &found)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case hasVariants && variantID == nil:
+		return fmt.Errorf("%w: product %d", models.ErrVariantRequired, productID)
+	case !hasVariants && variantID != nil:
+		return fmt.Errorf("%w: product %d", models.ErrVariantNotAllowed, productID)
+	case variantID != nil && !found:
+		return fmt.Errorf("%w: id %d of product %d", models.ErrVariantNotFound, *variantID, productID)
+	}
+	return nil
+}
+
+// checkVariantOptions verifies that options has a non-empty value for each
+// option type of the product and nothing else, and returns the values in
+// option order.
+func checkVariantOptions(tx *sql.Tx, productID int, options map[string]string) ([]string, error) {
+	rows, err := tx.Query("SELECT name FROM product_options WHERE product_id = $1 ORDER BY position", productID)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		values = append(values, options[name])
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%w: product %d has no options", models.ErrVariantOptions, productID)
+	}
+	if len(options) != len(values) || slices.Contains(values, "") {
+		return nil, fmt.Errorf("%w: a value is needed for each product option and nothing else", models.ErrVariantOptions)
+	}
+	return values, nil
+}
+
+// variantWriteError maps constraint violations of a variant insert or update
+// to domain errors.
+func variantWriteError(err error) error {
+	if isUniqueViolation(err) {
+		if constraintName(err) == "product_variants_sku_key" {
+			return models.ErrSKUTaken
+		}
+		return models.ErrVariantOptionsTaken
+	}
+	return err
+}
+
+// Create adds a variant to a product. The first variant can only be added
+// while the product has no stock of its own, since from then on the
+// product's stock is the sum of its variants.
+func (r *productVariantRepository) Create(variant models.ProductVariant) (*models.ProductVariant, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var stock int
+	var hasVariants bool
+	err = tx.QueryRow(`
+		SELECT stock, EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)
+		FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, variant.ProductID).Scan(&stock, &hasVariants)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrProductNotFound
+		}
+		return nil, err
+	}
+	if !hasVariants && stock != 0 {
+		return nil, models.ErrProductStockNotEmpty
+	}
+
+	values, err := checkVariantOptions(tx, variant.ProductID, variant.Options)
+	if err != nil {
+		return nil, err
+	}
+	if variant.Name == "" {
+		variant.Name = strings.Join(values, " / ")
+	}
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO product_variants (product_id, name, sku, price, stock, options)
+		VALUES ($1, $2, NULLIF($3, ''), $4, 0, $5) RETURNING id`,
+		variant.ProductID, variant.Name, variant.SKU, variant.Price, string(options),
+	).Scan(&id)
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+
+	if variant.Stock != 0 {
+		err := moveStock(tx, &models.StockMovement{
+			ProductID: variant.ProductID,
+			VariantID: &id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  variant.Stock,
+			Note:      "Initial stock",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.getByID(variant.ProductID, id)
+}
+
+// Update changes a variant's details. As with products, a different stock
+// value is booked as an adjustment movement.
+func (r *productVariantRepository) Update(productID, id int, variant models.ProductVariant) (*models.ProductVariant, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stock, err := lockVariant(tx, productID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values, err := checkVariantOptions(tx, productID, variant.Options)
+	if err != nil {
+		return nil, err
+	}
+	if variant.Name == "" {
+		variant.Name = strings.Join(values, " / ")
+	}
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE product_variants SET name = $1, sku = NULLIF($2, ''), price = $3, options = $4 WHERE id = $5",
+		variant.Name, variant.SKU, variant.Price, string(options), id)
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+
+	if delta := variant.Stock - stock; delta != 0 {
+		if err := ensureNotCounting(tx, productID); err != nil {
+			return nil, err
+		}
+		err := moveStock(tx, &models.StockMovement{
+			ProductID: productID,
+			VariantID: &id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  delta,
+			Note:      "Manual stock edit",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.getByID(productID, id)
+}
+
+// Delete removes a variant that was never sold, ordered or counted. Stock
+// left on it is written off first so the product total stays in step with
+// the ledger.
+func (r *productVariantRepository) Delete(productID, id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stock, err := lockVariant(tx, productID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrVariantNotFound
+		}
+		return err
+	}
+
+	if stock != 0 {
+		if err := ensureNotCounting(tx, productID); err != nil {
+			return err
+		}
+		err := moveStock(tx, &models.StockMovement{
+			ProductID: productID,
+			VariantID: &id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  -stock,
+			Note:      "Variant deleted",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_variants WHERE id = $1", id); err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrVariantInUse
+		}
+		return err
+	}
+	return tx.Commit()
+}
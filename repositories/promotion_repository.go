@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+	"time"
+)
+
+type PromotionRepository interface {
+	GetAll(limit, offset int) ([]models.Promotion, int, error)
+	GetByID(id int) (*models.Promotion, error)
+	Create(promotion models.Promotion) (*models.Promotion, error)
+	Update(id int, promotion models.Promotion) (*models.Promotion, error)
+	Delete(id int) error
+	GetApplicable(at time.Time) ([]models.Promotion, error)
+	GetCategoryPath(productID int) ([]int, error)
+}
+
+type promotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) PromotionRepository {
+	return &promotionRepository{db}
+}
+
+const promotionColumns = `
+	SELECT id, name, type, percent, amount, buy_quantity, free_quantity, target_type, target_id, COALESCE(code, ''),
+		stackable, priority, starts_at, ends_at, active, created_at
+	FROM promotions`
+
+func scanPromotion(row interface{ Scan(...any) error }) (models.Promotion, error) {
+	var p models.Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Percent, &p.Amount, &p.BuyQuantity, &p.FreeQuantity, &p.TargetType,
+		&p.TargetID, &p.Code, &p.Stackable, &p.Priority, &p.StartsAt, &p.EndsAt, &p.Active, &p.CreatedAt)
+	return p, err
+}
+
+func (r *promotionRepository) list(query string, args ...any) ([]models.Promotion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []models.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+func (r *promotionRepository) GetAll(limit, offset int) ([]models.Promotion, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM promotions").Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	promotions, err := r.list(promotionColumns+" ORDER BY id DESC LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
+
+func (r *promotionRepository) GetByID(id int) (*models.Promotion, error) {
+	p, err := scanPromotion(r.db.QueryRow(promotionColumns+" WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetApplicable lists the active promotions whose validity window contains
+// at, vouchers included, in the order they are applied.
+func (r *promotionRepository) GetApplicable(at time.Time) ([]models.Promotion, error) {
+	return r.list(promotionColumns+`
+		WHERE active AND (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY priority DESC, id`, at)
+}
+
+// GetCategoryPath returns the category of a product followed by its
+// ancestors, so category promotions also cover subcategories.
+func (r *promotionRepository) GetCategoryPath(productID int) ([]int, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE path AS (
+			SELECT c.id, c.parent_id, 0 AS depth
+			FROM products p JOIN categories c ON c.id = p.category_id
+			WHERE p.id = $1
+			UNION
+			SELECT c.id, c.parent_id, path.depth + 1
+			FROM categories c JOIN path ON c.id = path.parent_id
+			WHERE path.depth < 100
+		)
+		SELECT id FROM path ORDER BY depth`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// checkTarget verifies that the product or category a promotion targets
+// exists and isn't deleted.
+func (r *promotionRepository) checkTarget(promotion models.Promotion) error {
+	var query string
+	switch promotion.TargetType {
+	case models.PromotionTargetProduct:
+		query = "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)"
+	case models.PromotionTargetCategory:
+		query = "SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)"
+	default:
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(query, promotion.TargetID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrPromotionTargetNotFound
+	}
+	return nil
+}
+
+func (r *promotionRepository) Create(promotion models.Promotion) (*models.Promotion, error) {
+	if err := r.checkTarget(promotion); err != nil {
+		return nil, err
+	}
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO promotions (name, type, percent, amount, buy_quantity, free_quantity, target_type, target_id, code,
+			stackable, priority, starts_at, ends_at, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13, $14) RETURNING id`,
+		promotion.Name, promotion.Type, promotion.Percent, promotion.Amount, promotion.BuyQuantity, promotion.FreeQuantity,
+		promotion.TargetType, promotion.TargetID, promotion.Code, promotion.Stackable, promotion.Priority,
+		promotion.StartsAt, promotion.EndsAt, promotion.Active,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrPromoCodeTaken
+		}
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *promotionRepository) Update(id int, promotion models.Promotion) (*models.Promotion, error) {
+	if err := r.checkTarget(promotion); err != nil {
+		return nil, err
+	}
+
+	res, err := r.db.Exec(`
+		UPDATE promotions SET name = $1, type = $2, percent = $3, amount = $4, buy_quantity = $5, free_quantity = $6,
+			target_type = $7, target_id = $8, code = NULLIF($9, ''), stackable = $10, priority = $11,
+			starts_at = $12, ends_at = $13, active = $14
+		WHERE id = $15`,
+		promotion.Name, promotion.Type, promotion.Percent, promotion.Amount, promotion.BuyQuantity, promotion.FreeQuantity,
+		promotion.TargetType, promotion.TargetID, promotion.Code, promotion.Stackable, promotion.Priority,
+		promotion.StartsAt, promotion.EndsAt, promotion.Active, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrPromoCodeTaken
+		}
+		return nil, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return r.GetByID(id)
+}
+
+// Delete removes a promotion. Discounts it gave on past sales keep its name.
+func (r *promotionRepository) Delete(id int) error {
+	res, err := r.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrPromotionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type PurchaseOrderRepository interface {
+	GetAll(status models.PurchaseOrderStatus, limit, offset int) ([]models.PurchaseOrder, int, error)
+	GetByID(id int) (*models.PurchaseOrder, error)
+	Create(req models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	Update(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	Order(id int) (*models.PurchaseOrder, error)
+	Cancel(id int) (*models.PurchaseOrder, error)
+	Receive(id int, items []models.ReceiveItemRequest) (*models.PurchaseOrder, error)
+}
+
+type purchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) PurchaseOrderRepository {
+	return &purchaseOrderRepository{db}
+}
+
+const purchaseOrderColumns = `
+	SELECT po.id, po.supplier_id, s.name, po.status, po.note, po.created_at, po.ordered_at, po.received_at,
+		COALESCE((SELECT SUM(i.quantity * i.unit_cost)::bigint FROM purchase_order_items i WHERE i.purchase_order_id = po.id), 0)
+	FROM purchase_orders po
+	JOIN suppliers s ON po.supplier_id = s.id`
+
+func scanPurchaseOrder(row interface{ Scan(...any) error }) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note,
+		&po.CreatedAt, &po.OrderedAt, &po.ReceivedAt, &po.TotalCost)
+	return po, err
+}
+
+func (r *purchaseOrderRepository) GetAll(status models.PurchaseOrderStatus, limit, offset int) ([]models.PurchaseOrder, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM purchase_orders WHERE ($1 = '' OR status = $1)", status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(purchaseOrderColumns+`
+		WHERE ($1 = '' OR po.status = $1)
+		ORDER BY po.id DESC LIMIT $2 OFFSET $3`, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var orders []models.PurchaseOrder
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, po)
+	}
+	return orders, total, nil
+}
+
+func (r *purchaseOrderRepository) GetByID(id int) (*models.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(r.db.QueryRow(purchaseOrderColumns+" WHERE po.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT i.id, i.purchase_order_id, i.product_id, p.name, i.variant_id, COALESCE(v.name, ''),
+			i.quantity, i.received_quantity, i.unit_cost
+		FROM purchase_order_items i
+		JOIN products p ON i.product_id = p.id
+		LEFT JOIN product_variants v ON i.variant_id = v.id
+		WHERE i.purchase_order_id = $1
+		ORDER BY i.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&item.ID, &item.PurchaseOrderID, &item.ProductID, &item.ProductName,
+			&item.VariantID, &item.VariantName, &item.Quantity, &item.ReceivedQuantity, &item.UnitCost); err != nil {
+			return nil, err
+		}
+		po.Items = append(po.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// insertPurchaseOrderItems validates the supplier, products and variants
+// and writes the PO lines.
+func insertPurchaseOrderItems(tx *sql.Tx, id int, req models.PurchaseOrderRequest) error {
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1)", req.SupplierID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrSupplierNotFound
+	}
+
+	for _, item := range req.Items {
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", item.ProductID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: id %d", models.ErrProductNotFound, item.ProductID)
+		}
+		if err := checkVariantChoice(tx, item.ProductID, item.VariantID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO purchase_order_items (purchase_order_id, product_id, variant_id, quantity, unit_cost) VALUES ($1, $2, $3, $4, $5)",
+			id, item.ProductID, item.VariantID, item.Quantity, item.UnitCost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockPurchaseOrder locks the PO row and checks its status is one of allowed.
+func lockPurchaseOrder(tx *sql.Tx, id int, allowed ...models.PurchaseOrderStatus) error {
+	var status models.PurchaseOrderStatus
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrPurchaseOrderNotFound
+		}
+		return err
+	}
+	for _, s := range allowed {
+		if status == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: purchase order is %s", models.ErrInvalidPurchaseOrderTransition, status)
+}
+
+func (r *purchaseOrderRepository) Create(req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO purchase_orders (supplier_id, note) VALUES ($1, $2) RETURNING id",
+		req.SupplierID, req.Note,
+	).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, models.ErrSupplierNotFound
+		}
+		return nil, err
+	}
+
+	if err := insertPurchaseOrderItems(tx, id, req); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Update replaces the supplier, note and lines of a draft purchase order.
+func (r *purchaseOrderRepository) Update(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPurchaseOrder(tx, id, models.PurchaseOrderDraft); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM purchase_order_items WHERE purchase_order_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := insertPurchaseOrderItems(tx, id, req); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, note = $2 WHERE id = $3", req.SupplierID, req.Note, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *purchaseOrderRepository) Order(id int) (*models.PurchaseOrder, error) {
+	return r.transition(id, "status = 'ordered', ordered_at = now()", models.PurchaseOrderDraft)
+}
+
+func (r *purchaseOrderRepository) Cancel(id int) (*models.PurchaseOrder, error) {
+	return r.transition(id, "status = 'cancelled'", models.PurchaseOrderDraft, models.PurchaseOrderOrdered)
+}
+
+func (r *purchaseOrderRepository) transition(id int, set string, from ...models.PurchaseOrderStatus) (*models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPurchaseOrder(tx, id, from...); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET "+set+" WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Receive books goods into stock as purchase movements, records the unit
+// cost of each receipt, also in the product's cost history, and moves the
+// PO to partially_received or received.
+func (r *purchaseOrderRepository) Receive(id int, items []models.ReceiveItemRequest) (*models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = lockPurchaseOrder(tx, id, models.PurchaseOrderOrdered, models.PurchaseOrderPartiallyReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		var line models.PurchaseOrderItem
+		err := tx.QueryRow(`
+			SELECT i.id, i.product_id, p.name, i.variant_id, i.quantity, i.received_quantity, i.unit_cost
+			FROM purchase_order_items i
+			JOIN products p ON i.product_id = p.id
+			WHERE i.id = $1 AND i.purchase_order_id = $2`, item.PurchaseOrderItemID, id).
+			Scan(&line.ID, &line.ProductID, &line.ProductName, &line.VariantID, &line.Quantity, &line.ReceivedQuantity, &line.UnitCost)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("%w: id %d", models.ErrPurchaseOrderItemNotFound, item.PurchaseOrderItemID)
+			}
+			return nil, err
+		}
+
+		if remaining := line.Quantity - line.ReceivedQuantity; item.Quantity > remaining {
+			return nil, fmt.Errorf("%w: %s (ordered %d, already received %d, receiving %d)",
+				models.ErrReceiveExceedsOrdered, line.ProductName, line.Quantity, line.ReceivedQuantity, item.Quantity)
+		}
+
+		unitCost := line.UnitCost
+		if item.UnitCost != nil {
+			unitCost = *item.UnitCost
+		}
+
+		_, err = tx.Exec("UPDATE purchase_order_items SET received_quantity = received_quantity + $1 WHERE id = $2",
+			item.Quantity, line.ID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("INSERT INTO purchase_order_receipts (purchase_order_item_id, quantity, unit_cost) VALUES ($1, $2, $3)",
+			line.ID, item.Quantity, unitCost)
+		if err != nil {
+			return nil, err
+		}
+
+		err = recordCost(tx, &models.ProductCost{
+			ProductID:       line.ProductID,
+			VariantID:       line.VariantID,
+			UnitCost:        unitCost,
+			Quantity:        item.Quantity,
+			Source:          models.CostSourcePurchase,
+			PurchaseOrderID: &id,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = moveStock(tx, &models.StockMovement{
+			ProductID:     line.ProductID,
+			VariantID:     line.VariantID,
+			Type:          models.StockMovementPurchase,
+			Quantity:      item.Quantity,
+			ReferenceType: "purchase_order",
+			ReferenceID:   &id,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE purchase_orders SET
+			status = CASE WHEN NOT EXISTS (
+				SELECT 1 FROM purchase_order_items WHERE purchase_order_id = $1 AND received_quantity < quantity
+			) THEN 'received' ELSE 'partially_received' END,
+			received_at = CASE WHEN NOT EXISTS (
+				SELECT 1 FROM purchase_order_items WHERE purchase_order_id = $1 AND received_quantity < quantity
+			) THEN now() END
+		WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
@@ -0,0 +1,311 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+type ReportRepository interface {
+	Sales(from, to time.Time, timezone string, groupBy models.SalesGroupBy) ([]models.SalesReportRow, error)
+	SalesTotals(from, to time.Time, timezone string) (models.SalesReportTotals, error)
+	TopProducts(from, to time.Time, req models.TopProductsRequest) ([]models.TopProduct, error)
+	SlowMovers(since time.Time, categoryID, limit, offset int) ([]models.SlowMover, int, error)
+	Margin(from, to time.Time, timezone string, req models.MarginReportRequest) ([]models.MarginReportRow, error)
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db}
+}
+
+// salesCTE selects the paid sales made in [$1, $2) with the number of
+// items on each, what was refunded for their returns so far and, for
+// bucketing, their time in the timezone $3. Sums of bigints are numeric in
+// PostgreSQL, so they are cast back.
+const salesCTE = `
+	WITH sales AS (
+		SELECT t.id, t.shift_id, t.total_amount, t.discount_total, t.tax_total, t.change_amount,
+			t.created_at AT TIME ZONE $3::text AS local_time,
+			(SELECT COALESCE(SUM(d.quantity), 0) FROM transaction_details d WHERE d.transaction_id = t.id) AS items,
+			(SELECT COALESCE(SUM(r.refund_amount), 0) FROM sales_returns r WHERE r.transaction_id = t.id) AS refunds
+		FROM transactions t
+		WHERE t.payment_status = 'paid' AND t.created_at >= $1 AND t.created_at < $2
+	)`
+
+// salesGroupQueries select, per group: the group, cashier ID, revenue,
+// refunds, transaction count, items sold, discounts and tax. By payment
+// method only the amount paid with each method and refunded by it is
+// selected, since a sale split across tenders would otherwise count under
+// each of them. Sales recorded before shifts or payments were tracked fall
+// into an "unknown" group.
+var salesGroupQueries = map[models.SalesGroupBy]string{
+	models.SalesByDay: salesCTE + `
+		SELECT to_char(local_time, 'YYYY-MM-DD') AS grp, NULL::int, SUM(total_amount)::bigint, SUM(refunds)::bigint, COUNT(*), SUM(items)::bigint,
+			SUM(discount_total)::bigint, SUM(tax_total)::bigint
+		FROM sales
+		GROUP BY grp
+		ORDER BY grp`,
+	models.SalesByHour: salesCTE + `
+		SELECT to_char(local_time, 'YYYY-MM-DD"T"HH24:00') AS grp, NULL::int, SUM(total_amount)::bigint, SUM(refunds)::bigint, COUNT(*), SUM(items)::bigint,
+			SUM(discount_total)::bigint, SUM(tax_total)::bigint
+		FROM sales
+		GROUP BY grp
+		ORDER BY grp`,
+	models.SalesByCashier: salesCTE + `
+		SELECT COALESCE(u.username, 'unknown'), u.id, SUM(s.total_amount)::bigint AS revenue, SUM(s.refunds)::bigint, COUNT(*), SUM(s.items)::bigint,
+			SUM(s.discount_total)::bigint, SUM(s.tax_total)::bigint
+		FROM sales s
+		LEFT JOIN shifts sh ON s.shift_id = sh.id
+		LEFT JOIN users u ON sh.user_id = u.id
+		GROUP BY u.id, u.username
+		ORDER BY revenue DESC`,
+	models.SalesByPaymentMethod: salesCTE + `, paid AS (
+			SELECT COALESCE(p.method, 'unknown') AS method,
+				SUM(COALESCE(p.paid, s.total_amount) - CASE WHEN p.method = 'cash' THEN s.change_amount ELSE 0 END) AS revenue
+			FROM sales s
+			LEFT JOIN (
+				SELECT transaction_id, method, SUM(amount) AS paid
+				FROM transaction_payments
+				WHERE status = 'paid'
+				GROUP BY transaction_id, method
+			) p ON p.transaction_id = s.id
+			GROUP BY p.method
+		), refunded AS (
+			SELECT r.refund_method AS method, SUM(r.refund_amount) AS refunds
+			FROM sales_returns r
+			JOIN sales s ON r.transaction_id = s.id
+			GROUP BY r.refund_method
+		)
+		SELECT COALESCE(p.method, r.method), NULL::int, COALESCE(p.revenue, 0)::bigint AS revenue,
+			COALESCE(r.refunds, 0)::bigint, NULL::bigint, NULL::bigint, NULL::bigint, NULL::bigint
+		FROM paid p
+		FULL JOIN refunded r ON p.method = r.method
+		ORDER BY revenue DESC`,
+}
+
+// Sales aggregates the paid sales made in [from, to) by groupBy, bucketing
+// days and hours in timezone, an IANA name such as Asia/Jakarta. Grouped by
+// payment method, rows only carry the revenue and refunds.
+func (r *reportRepository) Sales(from, to time.Time, timezone string, groupBy models.SalesGroupBy) ([]models.SalesReportRow, error) {
+	rows, err := r.db.Query(salesGroupQueries[groupBy], from, to, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := make([]models.SalesReportRow, 0)
+	for rows.Next() {
+		var row models.SalesReportRow
+		if err := rows.Scan(&row.Group, &row.CashierID, &row.Revenue, &row.Refunds, &row.Transactions, &row.ItemsSold,
+			&row.Discounts, &row.Tax); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// SalesTotals sums up the paid sales made in [from, to).
+func (r *reportRepository) SalesTotals(from, to time.Time, timezone string) (models.SalesReportTotals, error) {
+	var totals models.SalesReportTotals
+	err := r.db.QueryRow(salesCTE+`
+		SELECT COALESCE(SUM(total_amount), 0)::bigint, COALESCE(SUM(refunds), 0)::bigint, COUNT(*),
+			COALESCE(SUM(items), 0)::bigint, COALESCE(SUM(discount_total), 0)::bigint, COALESCE(SUM(tax_total), 0)::bigint
+		FROM sales`, from, to, timezone).
+		Scan(&totals.Revenue, &totals.Refunds, &totals.Transactions, &totals.ItemsSold, &totals.Discounts, &totals.Tax)
+	return totals, err
+}
+
+// inCategory matches products of the category given in parameter n and of
+// its subcategories, or all products when the parameter is 0.
+func inCategory(n int) string {
+	return fmt.Sprintf(`($%[1]d::int = 0 OR p.category_id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $%[1]d
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree))`, n)
+}
+
+// productRankingOrder orders products best first, breaking ties on the
+// other figure and then the product ID.
+var productRankingOrder = map[models.ProductRanking]string{
+	models.RankByQuantity: "s.quantity DESC, s.revenue DESC, p.id",
+	models.RankByRevenue:  "s.revenue DESC, s.quantity DESC, p.id",
+}
+
+// TopProducts ranks the products sold in the paid sales made in [from, to).
+// Revenue is the line totals without tax and service charge.
+func (r *reportRepository) TopProducts(from, to time.Time, req models.TopProductsRequest) ([]models.TopProduct, error) {
+	order := productRankingOrder[req.By]
+	categoryOrder := ""
+	if req.PerCategory {
+		categoryOrder = "category_name, category_id, "
+	}
+	rows, err := r.db.Query(fmt.Sprintf(`
+		WITH sold AS (
+			SELECT d.product_id, SUM(d.quantity)::bigint AS quantity,
+				SUM(d.total - d.tax - d.service_charge)::bigint AS revenue, COUNT(DISTINCT t.id) AS transactions
+			FROM transaction_details d
+			JOIN transactions t ON d.transaction_id = t.id
+			WHERE t.payment_status = 'paid' AND t.created_at >= $1 AND t.created_at < $2
+			GROUP BY d.product_id
+		), ranked AS (
+			SELECT ROW_NUMBER() OVER (PARTITION BY CASE WHEN $4::boolean THEN p.category_id END ORDER BY %s) AS rank,
+				p.id, p.name, COALESCE(p.sku, ''), p.category_id, c.name AS category_name,
+				s.quantity, s.revenue, s.transactions
+			FROM sold s
+			JOIN products p ON s.product_id = p.id
+			JOIN categories c ON p.category_id = c.id
+			WHERE %s
+		)
+		SELECT * FROM ranked WHERE rank <= $3 ORDER BY %srank`, order, inCategory(5), categoryOrder),
+		from, to, req.Limit, req.PerCategory, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.TopProduct, 0)
+	for rows.Next() {
+		var p models.TopProduct
+		if err := rows.Scan(&p.Rank, &p.ProductID, &p.ProductName, &p.SKU, &p.CategoryID, &p.CategoryName,
+			&p.Quantity, &p.Revenue, &p.Transactions); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// SlowMovers lists the products, not deleted, without paid sales since
+// since, those holding the most stock by value first.
+func (r *reportRepository) SlowMovers(since time.Time, categoryID, limit, offset int) ([]models.SlowMover, int, error) {
+	query := `
+		FROM products p
+		JOIN categories c ON p.category_id = c.id
+		LEFT JOIN LATERAL (
+			SELECT MAX(t.created_at) AS sold_at
+			FROM transaction_details d
+			JOIN transactions t ON d.transaction_id = t.id
+			WHERE d.product_id = p.id AND t.payment_status = 'paid'
+		) last ON true
+		WHERE p.deleted_at IS NULL AND (last.sold_at IS NULL OR last.sold_at < $1) AND ` + inCategory(2)
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*)"+query, since, categoryID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, COALESCE(p.sku, ''), p.category_id, c.name, p.stock,
+			p.stock::bigint * p.price, last.sold_at`+query+`
+		ORDER BY p.stock::bigint * p.price DESC, last.sold_at NULLS FIRST, p.id
+		LIMIT $3 OFFSET $4`, since, categoryID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := make([]models.SlowMover, 0)
+	for rows.Next() {
+		var p models.SlowMover
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.SKU, &p.CategoryID, &p.CategoryName, &p.Stock,
+			&p.StockValue, &p.LastSoldAt); err != nil {
+			return nil, 0, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// costOfGoodsSold is the cost of a sale line of the costed_lines CTE by
+// method, NULL when no cost of its product or variant was recorded before
+// the sale.
+var costOfGoodsSold = map[models.CostMethod]string{
+	models.CostLast:            "l.quantity::bigint * l.last_cost",
+	models.CostWeightedAverage: "ROUND(l.quantity * COALESCE(l.cumulative_average_cost, l.last_cost))::bigint",
+}
+
+// marginGroupings select the group, product ID and category ID of a margin
+// report row, and group and order the rows.
+var marginGroupings = map[models.MarginGroupBy]struct{ columns, groupBy, orderBy string }{
+	models.MarginByProduct:  {"p.name, p.id, p.category_id", "p.id, p.name, p.category_id", "revenue DESC, p.id"},
+	models.MarginByCategory: {"c.name, NULL::int, c.id", "c.id, c.name", "revenue DESC, c.id"},
+	models.MarginByDay:      {"to_char(l.local_time, 'YYYY-MM-DD') AS grp, NULL::int, NULL::int", "grp", "grp"},
+	models.MarginByMonth:    {"to_char(l.local_time, 'YYYY-MM') AS grp, NULL::int, NULL::int", "grp", "grp"},
+}
+
+// Margin works out the revenue and cost of goods sold of the paid sales made
+// in [from, to) by req.GroupBy, costing every sale line at the cost its
+// product, or its variant, had when it was sold by req.Method. Only costs recorded before a
+// sale are used, so a sale made before its product's first cost is
+// uncosted. The average cost is cumulative: it averages every cost
+// recorded for the product up to the sale, not the cost of the stock still
+// on hand. Returns are taken off the lines they return, whenever they were
+// made: their quantity, and their refund without its share of tax and
+// service charge.
+func (r *reportRepository) Margin(from, to time.Time, timezone string, req models.MarginReportRequest) ([]models.MarginReportRow, error) {
+	grouping := marginGroupings[req.GroupBy]
+	rows, err := r.db.Query(fmt.Sprintf(`
+		WITH returned AS (
+			SELECT transaction_detail_id, SUM(quantity) AS quantity, SUM(refund_amount) AS refund
+			FROM sales_return_items
+			GROUP BY transaction_detail_id
+		), lines AS (
+			SELECT d.product_id, d.variant_id, d.quantity - COALESCE(r.quantity, 0) AS quantity,
+				d.total - d.tax - d.service_charge - COALESCE(ROUND(
+					r.refund * (d.total - d.tax - d.service_charge)::numeric / NULLIF(d.total, 0)), 0)::bigint AS revenue,
+				t.created_at, t.created_at AT TIME ZONE $3::text AS local_time
+			FROM transaction_details d
+			JOIN transactions t ON d.transaction_id = t.id
+			JOIN products p ON d.product_id = p.id
+			LEFT JOIN returned r ON r.transaction_detail_id = d.id
+			WHERE t.payment_status = 'paid' AND t.created_at >= $1 AND t.created_at < $2 AND %s
+		), costed_lines AS (
+			SELECT l.*,
+				(SELECT c.unit_cost FROM product_costs c
+					WHERE c.product_id = l.product_id AND c.variant_id IS NOT DISTINCT FROM l.variant_id
+						AND c.created_at <= l.created_at
+					ORDER BY c.created_at DESC, c.id DESC LIMIT 1) AS last_cost,
+				(SELECT SUM(c.quantity::bigint * c.unit_cost)::numeric / NULLIF(SUM(c.quantity), 0) FROM product_costs c
+					WHERE c.product_id = l.product_id AND c.variant_id IS NOT DISTINCT FROM l.variant_id
+						AND c.created_at <= l.created_at) AS cumulative_average_cost
+			FROM lines l
+		), costed AS (
+			SELECT l.*, %s AS cogs FROM costed_lines l
+		)
+		SELECT %s, SUM(l.quantity)::bigint, SUM(l.revenue)::bigint AS revenue, COALESCE(SUM(l.cogs), 0)::bigint,
+			COALESCE(SUM(l.quantity) FILTER (WHERE l.cogs IS NULL), 0)::bigint,
+			COALESCE(SUM(l.revenue) FILTER (WHERE l.cogs IS NULL), 0)::bigint
+		FROM costed l
+		JOIN products p ON l.product_id = p.id
+		JOIN categories c ON p.category_id = c.id
+		GROUP BY %s
+		ORDER BY %s`, inCategory(4), costOfGoodsSold[req.Method], grouping.columns, grouping.groupBy, grouping.orderBy),
+		from, to, timezone, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := make([]models.MarginReportRow, 0)
+	for rows.Next() {
+		var row models.MarginReportRow
+		if err := rows.Scan(&row.Group, &row.ProductID, &row.CategoryID, &row.Quantity, &row.Revenue, &row.COGS,
+			&row.UncostedQuantity, &row.UncostedRevenue); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
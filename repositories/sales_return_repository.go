@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type SalesReturnRepository interface {
+	Create(userID, transactionID int, req models.ReturnRequest) (*models.SalesReturn, error)
+	GetByTransactionID(transactionID int) ([]models.SalesReturn, error)
+}
+
+type salesReturnRepository struct {
+	db *sql.DB
+}
+
+func NewSalesReturnRepository(db *sql.DB) SalesReturnRepository {
+	return &salesReturnRepository{db}
+}
+
+// returnableLine is a transaction line together with how much of it has
+// already been returned.
+type returnableLine struct {
+	models.TransactionDetail
+	Returned int
+}
+
+// Create records a return against the lines of a transaction and books the
+// returned quantities back into stock, all in one database transaction. The
+// return is recorded in the user's open shift, which a cash refund requires
+// because it is paid out of that shift's drawer.
+func (r *salesReturnRepository) Create(userID, transactionID int, req models.ReturnRequest) (*models.SalesReturn, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	salesReturn := models.SalesReturn{TransactionID: transactionID, Reason: req.Reason, RefundMethod: req.RefundMethod}
+	shiftID, err := openShiftOf(tx, userID, false)
+	switch {
+	case err == nil:
+		salesReturn.ShiftID = &shiftID
+	case err == models.ErrNoOpenShift && req.RefundMethod != models.PaymentCash:
+	default:
+		return nil, err
+	}
+
+	// Locking the transaction row serializes concurrent returns against the
+	// same sale so the already-returned totals below stay accurate. Only paid
+	// sales can be returned; nothing was received for an unpaid one.
+	var status models.PaymentStatus
+	err = tx.QueryRow("SELECT payment_status FROM transactions WHERE id = $1 FOR UPDATE", transactionID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if status != models.PaymentStatusPaid {
+		return nil, fmt.Errorf("%w: id %d", models.ErrTransactionNotPaid, transactionID)
+	}
+
+	rows, err := tx.Query(`
+		SELECT d.id, d.product_id, p.name, d.variant_id, d.quantity, d.price, d.total,
+			COALESCE((SELECT SUM(ri.quantity) FROM sales_return_items ri WHERE ri.transaction_detail_id = d.id), 0)
+		FROM transaction_details d
+		JOIN products p ON d.product_id = p.id
+		WHERE d.transaction_id = $1`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[int]returnableLine)
+	for rows.Next() {
+		var l returnableLine
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.VariantID, &l.Quantity, &l.Price, &l.Total, &l.Returned); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines[l.ID] = l
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		line, ok := lines[item.TransactionDetailID]
+		if !ok {
+			return nil, fmt.Errorf("%w: id %d", models.ErrTransactionDetailNotFound, item.TransactionDetailID)
+		}
+		if remaining := line.Quantity - line.Returned; item.Quantity > remaining {
+			return nil, fmt.Errorf("%w: %s (sold %d, already returned %d, requested %d)",
+				models.ErrReturnExceedsSold, line.ProductName, line.Quantity, line.Returned, item.Quantity)
+		}
+
+		// Refund what was paid for the units, after discounts and with tax
+		// and service charge. Working from the cumulative quantity returned
+		// keeps the refunds of a line adding up to exactly what was paid for
+		// it once it is fully returned.
+		paid := line.Total
+		refund := paid.MulRatio(int64(line.Returned+item.Quantity), int64(line.Quantity), models.RoundDown) -
+			paid.MulRatio(int64(line.Returned), int64(line.Quantity), models.RoundDown)
+		salesReturn.RefundAmount += refund
+		salesReturn.Items = append(salesReturn.Items, models.SalesReturnItem{
+			TransactionDetailID: line.ID,
+			ProductID:           line.ProductID,
+			ProductName:         line.ProductName,
+			Quantity:            item.Quantity,
+			RefundAmount:        refund,
+		})
+	}
+
+	err = tx.QueryRow(
+		"INSERT INTO sales_returns (transaction_id, shift_id, reason, refund_method, refund_amount) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		salesReturn.TransactionID, salesReturn.ShiftID, salesReturn.Reason, salesReturn.RefundMethod, salesReturn.RefundAmount,
+	).Scan(&salesReturn.ID, &salesReturn.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range salesReturn.Items {
+		item := &salesReturn.Items[i]
+		item.ReturnID = salesReturn.ID
+		err := tx.QueryRow(
+			"INSERT INTO sales_return_items (return_id, transaction_detail_id, product_id, quantity, refund_amount) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			item.ReturnID, item.TransactionDetailID, item.ProductID, item.Quantity, item.RefundAmount,
+		).Scan(&item.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = moveStock(tx, &models.StockMovement{
+			ProductID:     item.ProductID,
+			VariantID:     lines[item.TransactionDetailID].VariantID,
+			Type:          models.StockMovementReturn,
+			Quantity:      item.Quantity,
+			ReferenceType: "sales_return",
+			ReferenceID:   &salesReturn.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &salesReturn, nil
+}
+
+func (r *salesReturnRepository) GetByTransactionID(transactionID int) ([]models.SalesReturn, error) {
+	rows, err := r.db.Query(`
+		SELECT id, transaction_id, shift_id, reason, refund_method, refund_amount, created_at
+		FROM sales_returns
+		WHERE transaction_id = $1
+		ORDER BY id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []models.SalesReturn
+	index := make(map[int]int)
+	for rows.Next() {
+		var sr models.SalesReturn
+		if err := rows.Scan(&sr.ID, &sr.TransactionID, &sr.ShiftID, &sr.Reason, &sr.RefundMethod, &sr.RefundAmount, &sr.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[sr.ID] = len(returns)
+		returns = append(returns, sr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemRows, err := r.db.Query(`
+		SELECT ri.id, ri.return_id, ri.transaction_detail_id, ri.product_id, p.name, ri.quantity, ri.refund_amount
+		FROM sales_return_items ri
+		JOIN sales_returns sr ON ri.return_id = sr.id
+		JOIN products p ON ri.product_id = p.id
+		WHERE sr.transaction_id = $1
+		ORDER BY ri.id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.SalesReturnItem
+		if err := itemRows.Scan(&item.ID, &item.ReturnID, &item.TransactionDetailID, &item.ProductID, &item.ProductName, &item.Quantity, &item.RefundAmount); err != nil {
+			return nil, err
+		}
+		sr := &returns[index[item.ReturnID]]
+		sr.Items = append(sr.Items, item)
+	}
+	return returns, itemRows.Err()
+}
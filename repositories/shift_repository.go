@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+type ShiftRepository interface {
+	GetAll(limit, offset int) ([]models.Shift, int, error)
+	GetByID(id int) (*models.Shift, error)
+	GetOpen(userID int) (*models.Shift, error)
+	Open(userID int, req models.OpenShiftRequest) (*models.Shift, error)
+	AddCashMovement(userID int, req models.CashMovementRequest) (*models.Shift, error)
+	Close(userID int, req models.CloseShiftRequest) (*models.Shift, error)
+}
+
+type shiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &shiftRepository{db}
+}
+
+const shiftColumns = `
+	SELECT s.id, s.user_id, u.username, s.status, s.opening_cash, s.cash_sales, s.cash_refunds, s.cash_in, s.cash_out,
+		s.expected_cash, s.counted_cash, s.variance, s.note, s.opened_at, s.closed_at
+	FROM shifts s
+	JOIN users u ON s.user_id = u.id`
+
+func scanShift(row interface{ Scan(...any) error }) (models.Shift, error) {
+	var s models.Shift
+	err := row.Scan(&s.ID, &s.UserID, &s.Username, &s.Status, &s.OpeningCash, &s.CashSales, &s.CashRefunds, &s.CashIn,
+		&s.CashOut, &s.ExpectedCash, &s.CountedCash, &s.Variance, &s.Note, &s.OpenedAt, &s.ClosedAt)
+	return s, err
+}
+
+// openShiftOf locks the open shift of a user for the rest of tx and returns
+// its ID, or ErrNoOpenShift. Sales lock it shared so that closing the shift,
+// which locks it exclusively, waits for them and counts them.
+func openShiftOf(tx *sql.Tx, userID int, exclusive bool) (int, error) {
+	lock := "FOR SHARE"
+	if exclusive {
+		lock = "FOR UPDATE"
+	}
+	var id int
+	err := tx.QueryRow("SELECT id FROM shifts WHERE user_id = $1 AND status = 'open' "+lock, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, models.ErrNoOpenShift
+	}
+	return id, err
+}
+
+// cashTotals works out the cash sales, cash refunds, cash in and cash out of
+// a shift from its payments, returns and cash movements, and its expected
+// cash from those.
+func cashTotals(q interface {
+	QueryRow(string, ...any) *sql.Row
+}, s *models.Shift) error {
+	err := q.QueryRow(`
+		SELECT
+			COALESCE((SELECT SUM(amount)::bigint FROM transaction_payments
+				WHERE shift_id = $1 AND method = 'cash' AND status = 'paid'), 0)
+			- COALESCE((SELECT SUM(t.change_amount)::bigint FROM transactions t
+				WHERE EXISTS (SELECT 1 FROM transaction_payments p
+					WHERE p.transaction_id = t.id AND p.shift_id = $1 AND p.method = 'cash')), 0),
+			COALESCE((SELECT SUM(refund_amount)::bigint FROM sales_returns WHERE shift_id = $1 AND refund_method = 'cash'), 0),
+			COALESCE((SELECT SUM(amount)::bigint FROM shift_cash_movements WHERE shift_id = $1 AND type = 'cash_in'), 0),
+			COALESCE((SELECT SUM(amount)::bigint FROM shift_cash_movements WHERE shift_id = $1 AND type = 'cash_out'), 0)`,
+		s.ID).Scan(&s.CashSales, &s.CashRefunds, &s.CashIn, &s.CashOut)
+	if err != nil {
+		return err
+	}
+	s.ExpectedCash = s.OpeningCash + s.CashSales - s.CashRefunds + s.CashIn - s.CashOut
+	return nil
+}
+
+func (r *shiftRepository) GetAll(limit, offset int) ([]models.Shift, int, error) {
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM shifts").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(shiftColumns+" ORDER BY s.opened_at DESC, s.id DESC LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	shifts := make([]models.Shift, 0)
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		shifts = append(shifts, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	for i := range shifts {
+		if shifts[i].Status == models.ShiftOpen {
+			if err := cashTotals(r.db, &shifts[i]); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return shifts, total, nil
+}
+
+// GetByID returns a shift with its cash movements, or nil, nil if it
+// doesn't exist.
+func (r *shiftRepository) GetByID(id int) (*models.Shift, error) {
+	s, err := scanShift(r.db.QueryRow(shiftColumns+" WHERE s.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if s.Status == models.ShiftOpen {
+		if err := cashTotals(r.db, &s); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, shift_id, type, amount, reason, created_at
+		FROM shift_cash_movements
+		WHERE shift_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Movements = []models.ShiftCashMovement{}
+	for rows.Next() {
+		var m models.ShiftCashMovement
+		if err := rows.Scan(&m.ID, &m.ShiftID, &m.Type, &m.Amount, &m.Reason, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Movements = append(s.Movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetOpen returns the open shift of a user, or nil, nil if there is none.
+func (r *shiftRepository) GetOpen(userID int) (*models.Shift, error) {
+	var id int
+	err := r.db.QueryRow("SELECT id FROM shifts WHERE user_id = $1 AND status = 'open'", userID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *shiftRepository) Open(userID int, req models.OpenShiftRequest) (*models.Shift, error) {
+	var id int
+	err := r.db.QueryRow("INSERT INTO shifts (user_id, opening_cash, note) VALUES ($1, $2, $3) RETURNING id",
+		userID, req.OpeningCash, req.Note).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrShiftAlreadyOpen
+		}
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// AddCashMovement records cash put into or taken out of the drawer during
+// the user's open shift.
+func (r *shiftRepository) AddCashMovement(userID int, req models.CashMovementRequest) (*models.Shift, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := openShiftOf(tx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("INSERT INTO shift_cash_movements (shift_id, type, amount, reason) VALUES ($1, $2, $3, $4)",
+		id, req.Type, req.Amount, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// Close closes the user's open shift with the cash counted in the drawer,
+// storing the expected cash and the variance.
+func (r *shiftRepository) Close(userID int, req models.CloseShiftRequest) (*models.Shift, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := openShiftOf(tx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scanShift(tx.QueryRow(shiftColumns+" WHERE s.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := cashTotals(tx, &s); err != nil {
+		return nil, err
+	}
+
+	variance := req.CountedCash - s.ExpectedCash
+	note := s.Note
+	if req.Note != "" {
+		note = req.Note
+	}
+	_, err = tx.Exec(`
+		UPDATE shifts SET status = 'closed', cash_sales = $1, cash_refunds = $2, cash_in = $3, cash_out = $4,
+			expected_cash = $5, counted_cash = $6, variance = $7, note = $8, closed_at = now()
+		WHERE id = $9`,
+		s.CashSales, s.CashRefunds, s.CashIn, s.CashOut, s.ExpectedCash, req.CountedCash, variance, note, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
@@ -0,0 +1,301 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type StockCountRepository interface {
+	GetAll(limit, offset int) ([]models.StockCount, int, error)
+	GetByID(id int) (*models.StockCount, error)
+	Open(req models.OpenStockCountRequest) (*models.StockCount, error)
+	SubmitCounts(id int, entries []models.StockCountEntry) (*models.StockCount, error)
+	Approve(id int) (*models.StockCount, error)
+	Cancel(id int) (*models.StockCount, error)
+}
+
+type stockCountRepository struct {
+	db *sql.DB
+}
+
+func NewStockCountRepository(db *sql.DB) StockCountRepository {
+	return &stockCountRepository{db}
+}
+
+// ensureNotCounting returns ErrStockCountInProgress when the product is in
+// the scope of an open stock count, so manual edits can't race the count.
+func ensureNotCounting(tx *sql.Tx, productID int) error {
+	var locked bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM stock_counts c
+			JOIN products p ON p.id = $1
+			WHERE c.status = 'open' AND (c.category_id IS NULL OR c.category_id = p.category_id)
+		)`, productID).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return models.ErrStockCountInProgress
+	}
+	return nil
+}
+
+func (r *stockCountRepository) GetAll(limit, offset int) ([]models.StockCount, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM stock_counts").Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, status, category_id, note, created_at, closed_at
+		FROM stock_counts
+		ORDER BY id DESC LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var counts []models.StockCount
+	for rows.Next() {
+		var c models.StockCount
+		if err := rows.Scan(&c.ID, &c.Status, &c.CategoryID, &c.Note, &c.CreatedAt, &c.ClosedAt); err != nil {
+			return nil, 0, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, total, nil
+}
+
+func (r *stockCountRepository) GetByID(id int) (*models.StockCount, error) {
+	var c models.StockCount
+	err := r.db.QueryRow(`
+		SELECT id, status, category_id, note, created_at, closed_at
+		FROM stock_counts WHERE id = $1`, id).
+		Scan(&c.ID, &c.Status, &c.CategoryID, &c.Note, &c.CreatedAt, &c.ClosedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Items compare against the stock snapshot taken when they were
+	// counted.
+	rows, err := r.db.Query(`
+		SELECT i.product_id, p.name, i.variant_id, COALESCE(v.name, ''), i.counted_quantity,
+			i.system_stock, i.counted_at
+		FROM stock_count_items i
+		JOIN products p ON i.product_id = p.id
+		LEFT JOIN product_variants v ON i.variant_id = v.id
+		WHERE i.stock_count_id = $1
+		ORDER BY i.product_id, i.variant_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.StockCountItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName, &item.CountedQuantity, &item.SystemStock, &item.CountedAt); err != nil {
+			return nil, err
+		}
+		item.Variance = item.CountedQuantity - item.SystemStock
+		c.Items = append(c.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *stockCountRepository) Open(req models.OpenStockCountRequest) (*models.StockCount, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO stock_counts (category_id, note) VALUES ($1, $2) RETURNING id",
+		req.CategoryID, req.Note,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, models.ErrStockCountAlreadyOpen
+		}
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// lockOpenCount locks the session row and checks that it is still open.
+func lockOpenCount(tx *sql.Tx, id int) (*models.StockCount, error) {
+	var c models.StockCount
+	err := tx.QueryRow("SELECT id, status, category_id FROM stock_counts WHERE id = $1 FOR UPDATE", id).
+		Scan(&c.ID, &c.Status, &c.CategoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrStockCountNotFound
+		}
+		return nil, err
+	}
+	if c.Status != models.StockCountOpen {
+		return nil, models.ErrStockCountNotOpen
+	}
+	return &c, nil
+}
+
+// SubmitCounts records counted quantities together with a snapshot of the
+// stock at the time of counting, which the count is later reconciled
+// against. Submitting a product or variant again overwrites its previous
+// count and snapshot, so a session can be filled in over several requests.
+func (r *stockCountRepository) SubmitCounts(id int, entries []models.StockCountEntry) (*models.StockCount, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	count, err := lockOpenCount(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		var categoryID int
+		err := tx.QueryRow("SELECT category_id FROM products WHERE id = $1", entry.ProductID).Scan(&categoryID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("%w: id %d", models.ErrProductNotFound, entry.ProductID)
+			}
+			return nil, err
+		}
+		if count.CategoryID != nil && *count.CategoryID != categoryID {
+			return nil, fmt.Errorf("%w: id %d", models.ErrProductOutOfCountScope, entry.ProductID)
+		}
+		if err := checkVariantChoice(tx, entry.ProductID, entry.VariantID); err != nil {
+			return nil, err
+		}
+		stock, err := lockCountedStock(tx, entry.ProductID, entry.VariantID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO stock_count_items (stock_count_id, product_id, variant_id, counted_quantity, system_stock)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (stock_count_id, product_id, COALESCE(variant_id, 0))
+			DO UPDATE SET counted_quantity = EXCLUDED.counted_quantity, system_stock = EXCLUDED.system_stock,
+				counted_at = now()`,
+			id, entry.ProductID, entry.VariantID, entry.CountedQuantity, stock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// lockCountedStock locks and returns the stock of a product, or of one of
+// its variants.
+func lockCountedStock(tx *sql.Tx, productID int, variantID *int) (int, error) {
+	if variantID != nil {
+		return lockVariant(tx, productID, *variantID)
+	}
+	var stock int
+	err := tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&stock)
+	return stock, err
+}
+
+// Approve posts an adjustment movement for every counted product or variant
+// whose count differs from its stock snapshot and closes the session,
+// atomically. Applying the variance against the snapshot rather than the
+// live stock keeps the sales and receipts made between counting and
+// approval.
+func (r *stockCountRepository) Approve(id int) (*models.StockCount, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOpenCount(tx, id); err != nil {
+		return nil, err
+	}
+
+	type countedItem struct {
+		models.StockCountEntry
+		snapshot int
+	}
+	rows, err := tx.Query(`
+		SELECT product_id, variant_id, counted_quantity, system_stock
+		FROM stock_count_items
+		WHERE stock_count_id = $1
+		ORDER BY product_id, variant_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	var entries []countedItem
+	for rows.Next() {
+		var e countedItem
+		if err := rows.Scan(&e.ProductID, &e.VariantID, &e.CountedQuantity, &e.snapshot); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if delta := e.CountedQuantity - e.snapshot; delta != 0 {
+			err := moveStock(tx, &models.StockMovement{
+				ProductID:     e.ProductID,
+				VariantID:     e.VariantID,
+				Type:          models.StockMovementAdjustment,
+				Quantity:      delta,
+				ReferenceType: "stock_count",
+				ReferenceID:   &id,
+				Note:          "Stock count variance",
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_counts SET status = $1, closed_at = now() WHERE id = $2", models.StockCountApproved, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *stockCountRepository) Cancel(id int) (*models.StockCount, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOpenCount(tx, id); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_counts SET status = $1, closed_at = now() WHERE id = $2", models.StockCountCancelled, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
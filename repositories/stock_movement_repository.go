@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+type StockMovementRepository interface {
+	GetByProductID(productID, limit, offset int) ([]models.StockMovement, int, error)
+	Create(movement models.StockMovement) (*models.StockMovement, error)
+	GetDiscrepancies() ([]models.StockDiscrepancy, error)
+}
+
+type stockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepository(db *sql.DB) StockMovementRepository {
+	return &stockMovementRepository{db}
+}
+
+// moveStock is the only place products.stock and product_variants.stock are
+// changed. It applies the signed quantity of m to the product and appends m
+// to the ledger, filling in StockAfter, ID and CreatedAt. Movements of a
+// product with variants must name the variant; its stock moves together
+// with the product total and StockAfter is the variant's stock. Stock
+// rising above the reorder point re-arms the product's low-stock alert. It
+// must run inside the caller's tx.
+func moveStock(tx *sql.Tx, m *models.StockMovement) error {
+	var stock int
+	var hasVariants bool
+	err := tx.QueryRow(`
+		SELECT stock, EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)
+		FROM products WHERE id = $1 FOR UPDATE`, m.ProductID).Scan(&stock, &hasVariants)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: id %d", models.ErrProductNotFound, m.ProductID)
+		}
+		return err
+	}
+
+	switch {
+	case hasVariants && m.VariantID == nil:
+		return fmt.Errorf("%w: product %d", models.ErrVariantRequired, m.ProductID)
+	case !hasVariants && m.VariantID != nil:
+		return fmt.Errorf("%w: product %d", models.ErrVariantNotAllowed, m.ProductID)
+	}
+
+	productStock := stock + m.Quantity
+	if productStock < 0 {
+		return fmt.Errorf("%w: product %d has %d, change %d", models.ErrNegativeStock, m.ProductID, stock, m.Quantity)
+	}
+	_, err = tx.Exec("UPDATE products SET stock = $1, low_stock_alerted = low_stock_alerted AND $1 <= reorder_point WHERE id = $2",
+		productStock, m.ProductID)
+	if err != nil {
+		return err
+	}
+	m.StockAfter = productStock
+
+	if m.VariantID != nil {
+		err := tx.QueryRow("SELECT stock FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE",
+			*m.VariantID, m.ProductID).Scan(&stock)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: id %d", models.ErrVariantNotFound, *m.VariantID)
+			}
+			return err
+		}
+		m.StockAfter = stock + m.Quantity
+		if m.StockAfter < 0 {
+			return fmt.Errorf("%w: variant %d has %d, change %d", models.ErrNegativeStock, *m.VariantID, stock, m.Quantity)
+		}
+		if _, err := tx.Exec("UPDATE product_variants SET stock = $1 WHERE id = $2", m.StockAfter, *m.VariantID); err != nil {
+			return err
+		}
+	}
+
+	var referenceType sql.NullString
+	if m.ReferenceType != "" {
+		referenceType = sql.NullString{String: m.ReferenceType, Valid: true}
+	}
+	return tx.QueryRow(`
+		INSERT INTO stock_movements (product_id, variant_id, type, quantity, stock_after, reference_type, reference_id, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+		m.ProductID, m.VariantID, m.Type, m.Quantity, m.StockAfter, referenceType, m.ReferenceID, m.Note,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+func (r *stockMovementRepository) GetByProductID(productID, limit, offset int) ([]models.StockMovement, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM stock_movements WHERE product_id = $1", productID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, product_id, variant_id, type, quantity, stock_after, COALESCE(reference_type, ''), reference_id, note, created_at
+		FROM stock_movements
+		WHERE product_id = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3`, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var movements []models.StockMovement
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.VariantID, &m.Type, &m.Quantity, &m.StockAfter, &m.ReferenceType, &m.ReferenceID, &m.Note, &m.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, total, nil
+}
+
+func (r *stockMovementRepository) Create(movement models.StockMovement) (*models.StockMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureNotCounting(tx, movement.ProductID); err != nil {
+		return nil, err
+	}
+	if err := moveStock(tx, &movement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// GetDiscrepancies lists products whose stock differs from their ledger
+// balance, e.g. after a change made directly in the database.
+func (r *stockMovementRepository) GetDiscrepancies() ([]models.StockDiscrepancy, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, p.stock, COALESCE(SUM(m.quantity), 0) AS ledger_stock
+		FROM products p
+		LEFT JOIN stock_movements m ON m.product_id = p.id
+		GROUP BY p.id, p.name, p.stock
+		HAVING p.stock <> COALESCE(SUM(m.quantity), 0)
+		ORDER BY p.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discrepancies []models.StockDiscrepancy
+	for rows.Next() {
+		var d models.StockDiscrepancy
+		if err := rows.Scan(&d.ProductID, &d.ProductName, &d.Stock, &d.LedgerStock); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	return discrepancies, rows.Err()
+}
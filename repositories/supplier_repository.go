@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+type SupplierRepository interface {
+	GetAll(limit, offset int) ([]models.Supplier, int, error)
+	GetByID(id int) (*models.Supplier, error)
+	Create(supplier models.Supplier) (models.Supplier, error)
+	Update(id int, supplier models.Supplier) (*models.Supplier, error)
+	Delete(id int) error
+}
+
+type supplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) SupplierRepository {
+	return &supplierRepository{db}
+}
+
+func (r *supplierRepository) GetAll(limit, offset int) ([]models.Supplier, int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM suppliers").Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query("SELECT id, name, phone, email, address FROM suppliers ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var suppliers []models.Supplier
+	for rows.Next() {
+		var s models.Supplier
+		if err := rows.Scan(&s.ID, &s.Name, &s.Phone, &s.Email, &s.Address); err != nil {
+			return nil, 0, err
+		}
+		suppliers = append(suppliers, s)
+	}
+	return suppliers, total, nil
+}
+
+func (r *supplierRepository) GetByID(id int) (*models.Supplier, error) {
+	var s models.Supplier
+	err := r.db.QueryRow("SELECT id, name, phone, email, address FROM suppliers WHERE id = $1", id).
+		Scan(&s.ID, &s.Name, &s.Phone, &s.Email, &s.Address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *supplierRepository) Create(supplier models.Supplier) (models.Supplier, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO suppliers (name, phone, email, address) VALUES ($1, $2, $3, $4) RETURNING id",
+		supplier.Name, supplier.Phone, supplier.Email, supplier.Address,
+	).Scan(&id)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	supplier.ID = id
+	return supplier, nil
+}
+
+func (r *supplierRepository) Update(id int, supplier models.Supplier) (*models.Supplier, error) {
+	res, err := r.db.Exec("UPDATE suppliers SET name=$1, phone=$2, email=$3, address=$4 WHERE id=$5",
+		supplier.Name, supplier.Phone, supplier.Email, supplier.Address, id)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	supplier.ID = id
+	return &supplier, nil
+}
+
+func (r *supplierRepository) Delete(id int) error {
+	_, err := r.db.Exec("DELETE FROM suppliers WHERE id=$1", id)
+	if isForeignKeyViolation(err) {
+		return models.ErrSupplierInUse
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+type TaxRateRepository interface {
+	GetAll() ([]models.TaxRate, error)
+	GetByID(id int) (*models.TaxRate, error)
+	Create(rate models.TaxRate) (models.TaxRate, error)
+	Update(id int, rate models.TaxRate) (*models.TaxRate, error)
+	Delete(id int) error
+	GetForProduct(productID int) (*models.TaxRate, error)
+}
+
+type taxRateRepository struct {
+	db *sql.DB
+}
+
+func NewTaxRateRepository(db *sql.DB) TaxRateRepository {
+	return &taxRateRepository{db}
+}
+
+// ensureTaxRateExists returns ErrTaxRateNotFound when id is set but names
+// no tax rate.
+func ensureTaxRateExists(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, id *int) error {
+	if id == nil {
+		return nil
+	}
+	var exists bool
+	if err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM tax_rates WHERE id = $1)", *id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrTaxRateNotFound
+	}
+	return nil
+}
+
+func (r *taxRateRepository) GetAll() ([]models.TaxRate, error) {
+	rows, err := r.db.Query("SELECT id, name, rate, inclusive FROM tax_rates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.TaxRate
+	for rows.Next() {
+		var t models.TaxRate
+		if err := rows.Scan(&t.ID, &t.Name, &t.Rate, &t.Inclusive); err != nil {
+			return nil, err
+		}
+		rates = append(rates, t)
+	}
+	return rates, rows.Err()
+}
+
+func (r *taxRateRepository) GetByID(id int) (*models.TaxRate, error) {
+	var t models.TaxRate
+	err := r.db.QueryRow("SELECT id, name, rate, inclusive FROM tax_rates WHERE id = $1", id).
+		Scan(&t.ID, &t.Name, &t.Rate, &t.Inclusive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetForProduct returns the tax rate a product is sold at: its own, or else
+// that of the nearest category up its category path that has one. It
+// returns nil when the product is untaxed.
+func (r *taxRateRepository) GetForProduct(productID int) (*models.TaxRate, error) {
+	var t models.TaxRate
+	err := r.db.QueryRow(`
+		WITH RECURSIVE path AS (
+			SELECT tax_rate_id, category_id AS next_id, 0 AS depth
+			FROM products WHERE id = $1
+			UNION ALL
+			SELECT c.tax_rate_id, c.parent_id, path.depth + 1
+			FROM categories c JOIN path ON c.id = path.next_id
+			WHERE path.tax_rate_id IS NULL AND path.depth < 100
+		)
+		SELECT t.id, t.name, t.rate, t.inclusive
+		FROM path JOIN tax_rates t ON t.id = path.tax_rate_id
+		ORDER BY path.depth LIMIT 1`, productID).
+		Scan(&t.ID, &t.Name, &t.Rate, &t.Inclusive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *taxRateRepository) Create(rate models.TaxRate) (models.TaxRate, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO tax_rates (name, rate, inclusive) VALUES ($1, $2, $3) RETURNING id",
+		rate.Name, rate.Rate, rate.Inclusive,
+	).Scan(&id)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	rate.ID = id
+	return rate, nil
+}
+
+// Update changes a tax rate. Past sales keep the rate they were taxed at.
+func (r *taxRateRepository) Update(id int, rate models.TaxRate) (*models.TaxRate, error) {
+	res, err := r.db.Exec("UPDATE tax_rates SET name=$1, rate=$2, inclusive=$3 WHERE id=$4",
+		rate.Name, rate.Rate, rate.Inclusive, id)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	rate.ID = id
+	return &rate, nil
+}
+
+func (r *taxRateRepository) Delete(id int) error {
+	res, err := r.db.Exec("DELETE FROM tax_rates WHERE id=$1", id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return models.ErrTaxRateInUse
+		}
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrTaxRateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,515 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+	"slices"
+	"sort"
+)
+
+type TransactionRepository interface {
+	Create(userID int, items []models.CheckoutItem, pricing PriceFunc, settle SettleFunc) (*models.Transaction, error)
+	Pay(userID, id int, settle SettleFunc) (*models.Transaction, error)
+	Cancel(id int) (*models.Transaction, error)
+	GetByID(id int) (*models.Transaction, error)
+}
+
+type transactionRepository struct {
+	db *sql.DB
+}
+
+func NewTransactionRepository(db *sql.DB) TransactionRepository {
+	return &transactionRepository{db}
+}
+
+// PriceFunc adjusts a transaction being checked out after its lines have
+// been priced from the catalogue and before it is stored, by setting the
+// discounts, tax, service charge and total of its details. The transaction
+// totals are summed up afterwards. Returning an error aborts the checkout.
+type PriceFunc func(t *models.Transaction) error
+
+// SettleFunc takes payment for a transaction whose totals are final, by
+// setting its Payments, Change, Rounding and PaymentStatus. It runs inside the
+// database transaction, so returning an error aborts the checkout or
+// payment and nothing is stored.
+type SettleFunc func(t *models.Transaction) error
+
+// Create validates stock, decrements it and stores the transaction with its
+// details in a single database transaction, as a sale of the user's open
+// shift. Product and variant rows are
+// locked with SELECT ... FOR UPDATE so concurrent checkouts cannot oversell
+// an item. Variant lines are priced and stocked from the variant, and pricing,
+// if not nil, applies discounts while the rows are locked. settle, if not
+// nil, then takes payment for the total; without it the transaction is
+// stored unpaid.
+func (r *transactionRepository) Create(userID int, items []models.CheckoutItem, pricing PriceFunc, settle SettleFunc) (*models.Transaction, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shiftID, err := openShiftOf(tx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock rows in ascending ID order, products before variants, so two
+	// checkouts sharing products always acquire locks in the same order and
+	// cannot deadlock.
+	var ids, variantIDs []int
+	for _, item := range items {
+		if !slices.Contains(ids, item.ProductID) {
+			ids = append(ids, item.ProductID)
+		}
+		if item.VariantID != nil {
+			variantIDs = append(variantIDs, *item.VariantID)
+		}
+	}
+	sort.Ints(ids)
+	sort.Ints(variantIDs)
+
+	products := make(map[int]models.Product, len(ids))
+	hasVariants := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		var p models.Product
+		var withVariants bool
+		err := tx.QueryRow(`
+			SELECT id, name, price, stock, EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)
+			FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+			Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &withVariants)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("%w: id %d", models.ErrProductNotFound, id)
+			}
+			return nil, err
+		}
+		products[id] = p
+		hasVariants[id] = withVariants
+	}
+
+	variants := make(map[int]models.ProductVariant, len(variantIDs))
+	for _, id := range variantIDs {
+		var v models.ProductVariant
+		err := tx.QueryRow("SELECT id, product_id, name, price, stock FROM product_variants WHERE id = $1 FOR UPDATE", id).
+			Scan(&v.ID, &v.ProductID, &v.Name, &v.Price, &v.Stock)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("%w: id %d", models.ErrVariantNotFound, id)
+			}
+			return nil, err
+		}
+		variants[id] = v
+	}
+
+	transaction := models.Transaction{ShiftID: &shiftID, Currency: models.StoreCurrency.Code}
+	for _, item := range items {
+		p := products[item.ProductID]
+		name, price, stock := p.Name, p.Price, p.Stock
+		detail := models.TransactionDetail{ProductID: p.ID, ProductName: p.Name, Quantity: item.Quantity}
+
+		if item.VariantID != nil {
+			v, ok := variants[*item.VariantID]
+			if !ok || v.ProductID != p.ID {
+				return nil, fmt.Errorf("%w: id %d of product %d", models.ErrVariantNotFound, *item.VariantID, p.ID)
+			}
+			detail.VariantID = item.VariantID
+			detail.VariantName = v.Name
+			name, price, stock = p.Name+" "+v.Name, v.Price, v.Stock
+		} else if hasVariants[p.ID] {
+			return nil, fmt.Errorf("%w: %s", models.ErrVariantRequired, p.Name)
+		}
+
+		if stock < item.Quantity {
+			return nil, fmt.Errorf("%w: %s (available %d, requested %d)",
+				models.ErrInsufficientStock, name, stock, item.Quantity)
+		}
+
+		detail.Price = price
+		detail.Subtotal = price.Times(item.Quantity)
+		detail.Total = detail.Subtotal
+		transaction.Details = append(transaction.Details, detail)
+	}
+
+	if pricing != nil {
+		if err := pricing(&transaction); err != nil {
+			return nil, err
+		}
+	}
+	sumTotals(&transaction)
+
+	transaction.PaymentStatus = models.PaymentStatusUnpaid
+	transaction.Payments = []models.Payment{}
+	if settle != nil {
+		if err := settle(&transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO transactions (shift_id, currency, subtotal, discount_total, tax_total, service_charge, rounding, total_amount,
+			payment_status, change_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at`,
+		transaction.ShiftID, transaction.Currency, transaction.Subtotal, transaction.DiscountTotal, transaction.TaxTotal,
+		transaction.ServiceCharge, transaction.Rounding, transaction.TotalAmount,
+		transaction.PaymentStatus, transaction.Change,
+	).Scan(&transaction.ID, &transaction.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertPayments(tx, &transaction, shiftID); err != nil {
+		return nil, err
+	}
+
+	for i := range transaction.Taxes {
+		tax := &transaction.Taxes[i]
+		tax.TransactionID = transaction.ID
+		err := tx.QueryRow(`
+			INSERT INTO transaction_taxes (transaction_id, tax_rate_id, name, rate, inclusive, taxable_amount, amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+			tax.TransactionID, tax.TaxRateID, tax.Name, tax.Rate, tax.Inclusive, tax.TaxableAmount, tax.Amount,
+		).Scan(&tax.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range transaction.Details {
+		d := &transaction.Details[i]
+		d.TransactionID = transaction.ID
+		err := tx.QueryRow(`
+			INSERT INTO transaction_details (transaction_id, product_id, variant_id, quantity, price, subtotal, discount,
+				tax_rate_id, tax_name, tax_rate, tax_inclusive, tax, service_charge, total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`,
+			d.TransactionID, d.ProductID, d.VariantID, d.Quantity, d.Price, d.Subtotal, d.Discount,
+			d.TaxRateID, d.TaxName, d.TaxRate, d.TaxInclusive, d.Tax, d.ServiceCharge, d.Total,
+		).Scan(&d.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range d.Discounts {
+			discount := &d.Discounts[j]
+			discount.TransactionDetailID = d.ID
+			err := tx.QueryRow(
+				"INSERT INTO transaction_discounts (transaction_detail_id, promotion_id, promotion_name, amount) VALUES ($1, $2, $3, $4) RETURNING id",
+				discount.TransactionDetailID, discount.PromotionID, discount.PromotionName, discount.Amount,
+			).Scan(&discount.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = moveStock(tx, &models.StockMovement{
+			ProductID:     d.ProductID,
+			VariantID:     d.VariantID,
+			Type:          models.StockMovementSale,
+			Quantity:      -d.Quantity,
+			ReferenceType: "transaction",
+			ReferenceID:   &transaction.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// Pay takes payment for an unpaid transaction through settle into the
+// drawer of the user's open shift, locking the transaction row so it cannot
+// be paid twice. It returns nil, nil if the transaction doesn't exist.
+func (r *transactionRepository) Pay(userID, id int, settle SettleFunc) (*models.Transaction, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shiftID, err := openShiftOf(tx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	t := models.Transaction{ID: id, Payments: []models.Payment{}}
+	err = tx.QueryRow("SELECT total_amount, payment_status FROM transactions WHERE id = $1 FOR UPDATE", id).
+		Scan(&t.TotalAmount, &t.PaymentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := ensureUnpaid(t.PaymentStatus, id); err != nil {
+		return nil, err
+	}
+
+	if err := settle(&t); err != nil {
+		return nil, err
+	}
+	if err := insertPayments(tx, &t, shiftID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE transactions SET payment_status = $1, change_amount = $2, rounding = $3 WHERE id = $4",
+		t.PaymentStatus, t.Change, t.Rounding, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// ensureUnpaid returns an error unless a transaction with the given payment
+// status can still be paid or cancelled.
+func ensureUnpaid(status models.PaymentStatus, id int) error {
+	switch status {
+	case models.PaymentStatusPaid:
+		return fmt.Errorf("%w: id %d", models.ErrTransactionAlreadyPaid, id)
+	case models.PaymentStatusCancelled:
+		return fmt.Errorf("%w: id %d", models.ErrTransactionCancelled, id)
+	}
+	return nil
+}
+
+// Cancel calls off an unpaid transaction, putting the stock of its lines
+// back through the ledger. It refuses while a QRIS payment is pending and
+// unexpired, since the customer may still pay it; expired ones are marked
+// so. It returns nil, nil if the transaction doesn't exist.
+func (r *transactionRepository) Cancel(id int) (*models.Transaction, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status models.PaymentStatus
+	err = tx.QueryRow("SELECT payment_status FROM transactions WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := ensureUnpaid(status, id); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transaction_payments SET status = 'expired'
+		WHERE transaction_id = $1 AND status = 'pending' AND expires_at <= now()`, id)
+	if err != nil {
+		return nil, err
+	}
+	var pending bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM transaction_payments WHERE transaction_id = $1 AND status = 'pending')", id).
+		Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, fmt.Errorf("%w: id %d", models.ErrPaymentPending, id)
+	}
+
+	rows, err := tx.Query("SELECT product_id, variant_id, quantity FROM transaction_details WHERE transaction_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	var movements []models.StockMovement
+	for rows.Next() {
+		m := models.StockMovement{Type: models.StockMovementCancellation, ReferenceType: "transaction", ReferenceID: &id}
+		if err := rows.Scan(&m.ProductID, &m.VariantID, &m.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range movements {
+		if err := moveStock(tx, &movements[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE transactions SET payment_status = 'cancelled' WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// insertPayments stores the payments of t, taken during a shift.
+func insertPayments(tx *sql.Tx, t *models.Transaction, shiftID int) error {
+	for i := range t.Payments {
+		p := &t.Payments[i]
+		p.TransactionID = t.ID
+		p.ShiftID = &shiftID
+		err := tx.QueryRow(`
+			INSERT INTO transaction_payments (transaction_id, method, amount, reference, status, shift_id)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+			p.TransactionID, p.Method, p.Amount, p.Reference, p.Status, p.ShiftID,
+		).Scan(&p.ID, &p.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sumTotals fills in the transaction totals and the tax summary from the
+// amounts on the lines.
+func sumTotals(t *models.Transaction) {
+	t.Subtotal, t.DiscountTotal, t.TaxTotal, t.ServiceCharge = 0, 0, 0, 0
+	t.TotalAmount = 0
+	t.Taxes = []models.TransactionTax{}
+	index := make(map[int]int)
+	for i := range t.Details {
+		d := &t.Details[i]
+		t.Subtotal += d.Subtotal
+		t.DiscountTotal += d.Discount
+		t.TaxTotal += d.Tax
+		t.ServiceCharge += d.ServiceCharge
+		t.TotalAmount += d.Total
+
+		if d.TaxRateID == nil {
+			continue
+		}
+		k, ok := index[*d.TaxRateID]
+		if !ok {
+			k = len(t.Taxes)
+			index[*d.TaxRateID] = k
+			t.Taxes = append(t.Taxes, models.TransactionTax{
+				TaxRateID: d.TaxRateID,
+				Name:      d.TaxName,
+				Rate:      d.TaxRate,
+				Inclusive: d.TaxInclusive,
+			})
+		}
+		taxable := d.Subtotal - d.Discount
+		if d.TaxInclusive {
+			taxable -= d.Tax
+		}
+		t.Taxes[k].TaxableAmount += taxable
+		t.Taxes[k].Amount += d.Tax
+	}
+}
+
+func (r *transactionRepository) GetByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
+	err := r.db.QueryRow(`
+		SELECT id, shift_id, currency, subtotal, discount_total, tax_total, service_charge, rounding, total_amount,
+			payment_status, change_amount, created_at
+		FROM transactions WHERE id = $1`, id).
+		Scan(&t.ID, &t.ShiftID, &t.Currency, &t.Subtotal, &t.DiscountTotal, &t.TaxTotal, &t.ServiceCharge, &t.Rounding, &t.TotalAmount,
+			&t.PaymentStatus, &t.Change, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT d.id, d.transaction_id, d.product_id, p.name, d.variant_id, COALESCE(v.name, ''), d.quantity, d.price, d.subtotal, d.discount,
+			d.tax_rate_id, d.tax_name, d.tax_rate, d.tax_inclusive, d.tax, d.service_charge, d.total
+		FROM transaction_details d
+		JOIN products p ON d.product_id = p.id
+		LEFT JOIN product_variants v ON d.variant_id = v.id
+		WHERE d.transaction_id = $1
+		ORDER BY d.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := make(map[int]int)
+	for rows.Next() {
+		var d models.TransactionDetail
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.VariantID, &d.VariantName, &d.Quantity, &d.Price, &d.Subtotal, &d.Discount,
+			&d.TaxRateID, &d.TaxName, &d.TaxRate, &d.TaxInclusive, &d.Tax, &d.ServiceCharge, &d.Total); err != nil {
+			return nil, err
+		}
+		index[d.ID] = len(t.Details)
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	discountRows, err := r.db.Query(`
+		SELECT td.id, td.transaction_detail_id, td.promotion_id, td.promotion_name, td.amount
+		FROM transaction_discounts td
+		JOIN transaction_details d ON td.transaction_detail_id = d.id
+		WHERE d.transaction_id = $1
+		ORDER BY td.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer discountRows.Close()
+
+	for discountRows.Next() {
+		var discount models.TransactionDiscount
+		if err := discountRows.Scan(&discount.ID, &discount.TransactionDetailID, &discount.PromotionID, &discount.PromotionName, &discount.Amount); err != nil {
+			return nil, err
+		}
+		d := &t.Details[index[discount.TransactionDetailID]]
+		d.Discounts = append(d.Discounts, discount)
+	}
+	if err := discountRows.Err(); err != nil {
+		return nil, err
+	}
+
+	taxRows, err := r.db.Query(`
+		SELECT id, transaction_id, tax_rate_id, name, rate, inclusive, taxable_amount, amount
+		FROM transaction_taxes
+		WHERE transaction_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer taxRows.Close()
+
+	t.Taxes = []models.TransactionTax{}
+	for taxRows.Next() {
+		var tax models.TransactionTax
+		if err := taxRows.Scan(&tax.ID, &tax.TransactionID, &tax.TaxRateID, &tax.Name, &tax.Rate, &tax.Inclusive, &tax.TaxableAmount, &tax.Amount); err != nil {
+			return nil, err
+		}
+		t.Taxes = append(t.Taxes, tax)
+	}
+	if err := taxRows.Err(); err != nil {
+		return nil, err
+	}
+
+	paymentRows, err := r.db.Query(paymentColumns+" WHERE transaction_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	t.Payments = []models.Payment{}
+	for paymentRows.Next() {
+		p, err := scanPayment(paymentRows)
+		if err != nil {
+			return nil, err
+		}
+		t.Payments = append(t.Payments, p)
+	}
+	if err := paymentRows.Err(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+type UserRepository interface {
+	GetAll(limit, offset int) ([]models.User, int, error)
+	GetByID(id int) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	Create(user models.User) (models.User, error)
+	Count() (int, error)
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db}
+}
+
+func (r *userRepository) GetAll(limit, offset int) ([]models.User, int, error) {
+	total, err := r.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query("SELECT id, username, name, role, created_at FROM users ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) GetByID(id int) (*models.User, error) {
+	return r.getOne("SELECT id, username, name, role, password_hash, created_at FROM users WHERE id = $1", id)
+}
+
+func (r *userRepository) GetByUsername(username string) (*models.User, error) {
+	return r.getOne("SELECT id, username, name, role, password_hash, created_at FROM users WHERE username = $1", username)
+}
+
+func (r *userRepository) getOne(query string, arg interface{}) (*models.User, error) {
+	var u models.User
+	err := r.db.QueryRow(query, arg).Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.PasswordHash, &u.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *userRepository) Create(user models.User) (models.User, error) {
+	err := r.db.QueryRow(
+		"INSERT INTO users (username, name, role, password_hash) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		user.Username, user.Name, user.Role, user.PasswordHash,
+	).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.User{}, models.ErrUsernameTaken
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) Count() (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT count(*) FROM users").Scan(&total)
+	return total, err
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+type AuthService interface {
+	Login(username, password string) (*models.AuthToken, error)
+	Refresh(refreshToken string) (*models.AuthToken, error)
+	ParseAccessToken(accessToken string) (*models.AuthUser, error)
+}
+
+type AuthConfig struct {
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type authClaims struct {
+	Username  string      `json:"username"`
+	Role      models.Role `json:"role"`
+	TokenType string      `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+type authService struct {
+	repository repositories.UserRepository
+	config     AuthConfig
+}
+
+func NewAuthService(repo repositories.UserRepository, config AuthConfig) AuthService {
+	return &authService{repository: repo, config: config}
+}
+
+func (s *authService) Login(username, password string) (*models.AuthToken, error) {
+	user, err := s.repository.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *authService) Refresh(refreshToken string) (*models.AuthToken, error) {
+	claims, err := s.parse(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload the user so deleted accounts can't keep refreshing and role
+	// changes take effect on the next refresh.
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	user, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, models.ErrInvalidToken
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *authService) ParseAccessToken(accessToken string) (*models.AuthUser, error) {
+	claims, err := s.parse(accessToken, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	return &models.AuthUser{ID: id, Username: claims.Username, Role: claims.Role}, nil
+}
+
+func (s *authService) issueTokens(user *models.User) (*models.AuthToken, error) {
+	accessToken, err := s.sign(user, tokenTypeAccess, s.config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.sign(user, tokenTypeRefresh, s.config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.config.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *authService) sign(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := authClaims{
+		Username:  user.Username,
+		Role:      user.Role,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.Secret))
+}
+
+func (s *authService) parse(tokenString, tokenType string) (*authClaims, error) {
+	var claims authClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.config.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errors.Join(models.ErrInvalidToken, err)
+	}
+	if claims.TokenType != tokenType {
+		return nil, models.ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -7,11 +7,13 @@ import (
 )
 
 type CategoryService interface {
-	GetAllCategories(page, pageSize int) ([]models.Category, *utils.PaginationMeta, error)
-	GetCategoryByID(id int) (*models.Category, error)
+	GetAllCategories(page, pageSize int, includeDeleted bool) ([]models.Category, *utils.PaginationMeta, error)
+	GetCategoryByID(id int, includeDeleted bool) (*models.Category, error)
+	GetCategoryTree() ([]*models.CategoryNode, error)
 	CreateCategory(category models.Category) (models.Category, error)
 	UpdateCategory(id int, category models.Category) (*models.Category, error)
-	DeleteCategory(id int) error
+	DeleteCategory(id int, reassignTo *int) error
+	RestoreCategory(id int) (*models.Category, error)
 }
 
 type categoryService struct {
@@ -22,7 +24,7 @@ func NewCategoryService(repo repositories.CategoryRepository) CategoryService {
 	return &categoryService{repository: repo}
 }
 
-func (s *categoryService) GetAllCategories(page, pageSize int) ([]models.Category, *utils.PaginationMeta, error) {
+func (s *categoryService) GetAllCategories(page, pageSize int, includeDeleted bool) ([]models.Category, *utils.PaginationMeta, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -31,7 +33,7 @@ func (s *categoryService) GetAllCategories(page, pageSize int) ([]models.Categor
 	}
 
 	offset := (page - 1) * pageSize
-	categories, total, err := s.repository.GetAll(pageSize, offset)
+	categories, total, err := s.repository.GetAll(pageSize, offset, includeDeleted)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -50,18 +52,54 @@ func (s *categoryService) GetAllCategories(page, pageSize int) ([]models.Categor
 	return categories, meta, nil
 }
 
-func (s *categoryService) GetCategoryByID(id int) (*models.Category, error) {
-	return s.repository.GetByID(id)
+func (s *categoryService) GetCategoryByID(id int, includeDeleted bool) (*models.Category, error) {
+	return s.repository.GetByID(id, includeDeleted)
+}
+
+// GetCategoryTree returns the root categories with their subcategories
+// nested below them, each level sorted by name.
+func (s *categoryService) GetCategoryTree() ([]*models.CategoryNode, error) {
+	categories, err := s.repository.GetAllUnpaged()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{Category: c, Children: []*models.CategoryNode{}}
+	}
+
+	roots := []*models.CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return roots, nil
 }
 
 func (s *categoryService) CreateCategory(category models.Category) (models.Category, error) {
 	return s.repository.Create(category)
 }
 
+// UpdateCategory rejects a parent that is missing, deleted, or the category
+// itself or one of its descendants, which would cut the subtree off from
+// the root.
 func (s *categoryService) UpdateCategory(id int, category models.Category) (*models.Category, error) {
 	return s.repository.Update(id, category)
 }
 
-func (s *categoryService) DeleteCategory(id int) error {
-	return s.repository.Delete(id)
+// DeleteCategory deletes a category, first moving its products and
+// subcategories to reassignTo when given.
+func (s *categoryService) DeleteCategory(id int, reassignTo *int) error {
+	return s.repository.Delete(id, reassignTo)
+}
+
+func (s *categoryService) RestoreCategory(id int) (*models.Category, error) {
+	return s.repository.Restore(id)
 }
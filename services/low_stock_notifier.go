@@ -0,0 +1,48 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"kasir-api/models"
+	"log"
+	"net/http"
+)
+
+// LowStockNotifier delivers low-stock events, e.g. to the purchasing staff.
+type LowStockNotifier interface {
+	Notify(event models.LowStockEvent) error
+}
+
+// LogNotifier writes low-stock events to the standard logger.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(event models.LowStockEvent) error {
+	p := event.Product
+	log.Printf("low stock: %s (product %d) is down to %d after transaction %d, reorder point %d, reorder %d",
+		p.ProductName, p.ProductID, p.Stock, event.TransactionID, p.ReorderPoint, p.ReorderQty)
+	return nil
+}
+
+// WebhookNotifier POSTs low-stock events as JSON to URL. Responses other
+// than 2xx are errors.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(event models.LowStockEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("low-stock webhook responded %s", resp.Status)
+	}
+	return nil
+}
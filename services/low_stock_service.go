@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"log"
+	"time"
+)
+
+type LowStockService interface {
+	GetLowStockProducts() ([]models.LowStockProduct, error)
+}
+
+type lowStockService struct {
+	repository repositories.LowStockRepository
+}
+
+func NewLowStockService(repo repositories.LowStockRepository) LowStockService {
+	return &lowStockService{repository: repo}
+}
+
+func (s *lowStockService) GetLowStockProducts() ([]models.LowStockProduct, error) {
+	return s.repository.GetAll()
+}
+
+// StockWatcher is told which products a sale took stock from. StockSold
+// must not block the sale.
+type StockWatcher interface {
+	StockSold(transactionID int, productIDs []int)
+}
+
+type stockSale struct {
+	transactionID int
+	productIDs    []int
+	at            time.Time
+}
+
+// LowStockChecker is a StockWatcher that checks in the background whether
+// sales took products down to or below their reorder point and sends a
+// low-stock event for each to its notifier. A product alerts once until
+// its stock rises above the reorder point again.
+type LowStockChecker struct {
+	repository repositories.LowStockRepository
+	notifier   LowStockNotifier
+	sales      chan stockSale
+}
+
+// NewLowStockChecker returns a checker queueing up to queueSize sales
+// waiting to be checked. Run must be started for them to be.
+func NewLowStockChecker(repo repositories.LowStockRepository, notifier LowStockNotifier, queueSize int) *LowStockChecker {
+	return &LowStockChecker{repository: repo, notifier: notifier, sales: make(chan stockSale, queueSize)}
+}
+
+// StockSold queues a sale for checking. When the queue is full the sale is
+// skipped; its products are checked again on their next sale.
+func (c *LowStockChecker) StockSold(transactionID int, productIDs []int) {
+	select {
+	case c.sales <- stockSale{transactionID: transactionID, productIDs: productIDs, at: time.Now()}:
+	default:
+		log.Printf("low-stock check of transaction %d skipped: queue full", transactionID)
+	}
+}
+
+// Run checks queued sales until ctx is done.
+func (c *LowStockChecker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sale := <-c.sales:
+			c.check(sale)
+		}
+	}
+}
+
+// check claims the alerts of the low products of a sale and notifies them.
+// An alert that can't be delivered is released so a later sale retries it.
+func (c *LowStockChecker) check(sale stockSale) {
+	products, err := c.repository.ClaimAlerts(sale.productIDs)
+	if err != nil {
+		log.Printf("low-stock check of transaction %d failed: %v", sale.transactionID, err)
+		return
+	}
+	for _, p := range products {
+		event := models.LowStockEvent{Product: p, TransactionID: sale.transactionID, OccurredAt: sale.at}
+		if err := c.notifier.Notify(event); err != nil {
+			log.Printf("low-stock alert of product %d failed: %v", p.ProductID, err)
+			if err := c.repository.ReleaseAlert(p.ProductID); err != nil {
+				log.Printf("low-stock alert of product %d not released: %v", p.ProductID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+)
+
+// PaymentProvider takes payment for one tender. Cash and cards run through
+// a standalone EDC terminal are taken at the counter by ManualPaymentProvider;
+// card and e-wallet integrations implement it to charge through their
+// gateway. Charge and Void are called while the sale's rows are locked, so
+// they should not block for long.
+type PaymentProvider interface {
+	// Charge takes the payment and returns the provider's reference for it.
+	// A payment that was refused returns an error.
+	Charge(payment models.Payment) (reference string, err error)
+	// Void cancels a charge when the sale it paid for could not be
+	// completed.
+	Void(payment models.Payment) error
+}
+
+// PaymentProviders maps each accepted payment method to its provider.
+type PaymentProviders map[models.PaymentMethod]PaymentProvider
+
+// ManualPaymentProvider accepts payments taken at the counter, keeping the
+// reference the cashier entered, such as an EDC approval code.
+type ManualPaymentProvider struct{}
+
+func (ManualPaymentProvider) Charge(payment models.Payment) (string, error) {
+	return payment.Reference, nil
+}
+
+func (ManualPaymentProvider) Void(models.Payment) error {
+	return nil
+}
+
+// tenderChange checks that tenders cover total and returns the change due
+// and the cash rounding. Only cash can be overpaid: the other tenders
+// together may not exceed the total. When cash settles the balance they
+// leave, that balance is rounded to a multiple of cashUnit with mode and the
+// difference is returned as rounding; amounts paid otherwise are never
+// rounded.
+func tenderChange(total models.Money, tenders []models.PaymentRequest, cashUnit models.Money, mode models.RoundingMode) (change, rounding models.Money, err error) {
+	var nonCash, cash models.Money
+	for _, tender := range tenders {
+		if tender.Method == models.PaymentCash {
+			cash += tender.Amount
+		} else {
+			nonCash += tender.Amount
+		}
+	}
+	if nonCash > total {
+		return 0, 0, fmt.Errorf("%w: total %s, non-cash %s", models.ErrNonCashOverpayment, total, nonCash)
+	}
+
+	due := total - nonCash
+	if cash > 0 && due > 0 {
+		rounding = cashRounding(due, cashUnit, mode)
+		due += rounding
+	}
+	if cash < due {
+		return 0, 0, fmt.Errorf("%w: total %s, tendered %s", models.ErrPaymentInsufficient, total+rounding, nonCash+cash)
+	}
+	return cash - due, rounding, nil
+}
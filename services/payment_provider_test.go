@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"sync"
+	"testing"
+)
+
+// fakePaymentProvider approves every charge unless decline is set, and
+// records the payments it charged and voided.
+type fakePaymentProvider struct {
+	decline bool
+
+	mu      sync.Mutex
+	seq     int
+	charged []models.Payment
+	voided  []models.Payment
+}
+
+func (f *fakePaymentProvider) Charge(payment models.Payment) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.decline {
+		return "", errors.New("declined by fake provider")
+	}
+	f.seq++
+	payment.Reference = fmt.Sprintf("FAKE-%d", f.seq)
+	f.charged = append(f.charged, payment)
+	return payment.Reference, nil
+}
+
+func (f *fakePaymentProvider) Void(payment models.Payment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.voided = append(f.voided, payment)
+	return nil
+}
+
+// fakeTransactionRepository pays a stored unpaid transaction without a
+// database. Methods it doesn't override panic.
+type fakeTransactionRepository struct {
+	repositories.TransactionRepository
+	transaction models.Transaction
+}
+
+func (r *fakeTransactionRepository) Pay(userID, id int, settle repositories.SettleFunc) (*models.Transaction, error) {
+	t := r.transaction
+	if err := settle(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func cash(amount models.Money) models.PaymentRequest {
+	return models.PaymentRequest{Method: models.PaymentCash, Amount: amount}
+}
+
+func card(amount models.Money) models.PaymentRequest {
+	return models.PaymentRequest{Method: models.PaymentDebitCard, Amount: amount}
+}
+
+func TestTenderChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        models.Money
+		tenders      []models.PaymentRequest
+		unit         models.Money
+		wantChange   models.Money
+		wantRounding models.Money
+		wantErr      error
+	}{
+		{"exact cash", 10000, []models.PaymentRequest{cash(10000)}, 0, 0, 0, nil},
+		{"cash overpaid", 10000, []models.PaymentRequest{cash(20000)}, 0, 10000, 0, nil},
+		{"cash short", 10000, []models.PaymentRequest{cash(9000)}, 0, 0, 0, models.ErrPaymentInsufficient},
+		{"split short", 10000, []models.PaymentRequest{card(4000), cash(5000)}, 0, 0, 0, models.ErrPaymentInsufficient},
+		{"non-cash short", 10000, []models.PaymentRequest{card(9000)}, 0, 0, 0, models.ErrPaymentInsufficient},
+		{"no tenders", 10000, nil, 0, 0, 0, models.ErrPaymentInsufficient},
+		{"non-cash overpaid", 10000, []models.PaymentRequest{card(12000)}, 0, 0, 0, models.ErrNonCashOverpayment},
+		{"non-cash overpaid with cash", 10000, []models.PaymentRequest{card(11000), cash(5000)}, 0, 0, 0, models.ErrNonCashOverpayment},
+		{"change only from cash", 10000, []models.PaymentRequest{card(6000), cash(5000)}, 0, 1000, 0, nil},
+		{"cash rounded down", 12340, []models.PaymentRequest{cash(12300)}, 100, 0, -40, nil},
+		{"cash rounded up", 12350, []models.PaymentRequest{cash(12400)}, 100, 0, 50, nil},
+		{"cash short of rounded due", 12350, []models.PaymentRequest{cash(12350)}, 100, 0, 0, models.ErrPaymentInsufficient},
+		{"only the cash balance rounded", 12340, []models.PaymentRequest{card(2000), cash(20000)}, 100, 9700, -40, nil},
+		{"non-cash settles the total", 12340, []models.PaymentRequest{card(12340), cash(1000)}, 100, 1000, 0, nil},
+		{"non-cash never rounded", 12340, []models.PaymentRequest{card(12340)}, 100, 0, 0, nil},
+		{"rounding disabled", 12340, []models.PaymentRequest{cash(12340)}, 0, 0, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, rounding, err := tenderChange(tt.total, tt.tenders, tt.unit, models.RoundHalfUp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if change != tt.wantChange || rounding != tt.wantRounding {
+				t.Errorf("change, rounding = %d, %d, want %d, %d", change, rounding, tt.wantChange, tt.wantRounding)
+			}
+		})
+	}
+}
+
+func TestSettle(t *testing.T) {
+	cashProvider, cardProvider := &fakePaymentProvider{}, &fakePaymentProvider{}
+	s := &transactionService{
+		providers: PaymentProviders{models.PaymentCash: cashProvider, models.PaymentDebitCard: cardProvider},
+		config:    PricingConfig{Rounding: models.RoundHalfUp, CashRounding: 100},
+	}
+
+	var charged []models.Payment
+	transaction := models.Transaction{TotalAmount: 12340, PaymentStatus: models.PaymentStatusUnpaid}
+	if err := s.settle([]models.PaymentRequest{card(2000), cash(20000)}, &charged)(&transaction); err != nil {
+		t.Fatal(err)
+	}
+
+	if transaction.PaymentStatus != models.PaymentStatusPaid {
+		t.Errorf("payment status = %s, want paid", transaction.PaymentStatus)
+	}
+	if transaction.Change != 9700 || transaction.Rounding != -40 {
+		t.Errorf("change, rounding = %d, %d, want 9700, -40", transaction.Change, transaction.Rounding)
+	}
+	var paid models.Money
+	for _, p := range transaction.Payments {
+		paid += p.Amount
+	}
+	if paid != transaction.TotalAmount+transaction.Rounding+transaction.Change {
+		t.Errorf("payments add up to %d, want total %d + rounding %d + change %d",
+			paid, transaction.TotalAmount, transaction.Rounding, transaction.Change)
+	}
+	if len(charged) != 2 || len(cashProvider.charged) != 1 || len(cardProvider.charged) != 1 {
+		t.Fatalf("charged %d payments (cash %d, card %d), want 2 (1, 1)",
+			len(charged), len(cashProvider.charged), len(cardProvider.charged))
+	}
+	if transaction.Payments[0].Reference != "FAKE-1" {
+		t.Errorf("card reference = %q, want the provider's", transaction.Payments[0].Reference)
+	}
+}
+
+func TestSettleChargesNothingWhenRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenders []models.PaymentRequest
+		wantErr error
+	}{
+		{"not covered", []models.PaymentRequest{card(4000), cash(5000)}, models.ErrPaymentInsufficient},
+		{"non-cash overpaid", []models.PaymentRequest{card(12000)}, models.ErrNonCashOverpayment},
+		{"method without provider", []models.PaymentRequest{cash(5000), {Method: models.PaymentEWallet, Amount: 5000}},
+			models.ErrPaymentMethodNotSupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakePaymentProvider{}
+			s := &transactionService{providers: PaymentProviders{models.PaymentCash: provider, models.PaymentDebitCard: provider}}
+
+			var charged []models.Payment
+			transaction := models.Transaction{TotalAmount: 10000}
+			err := s.settle(tt.tenders, &charged)(&transaction)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(provider.charged) != 0 || len(charged) != 0 {
+				t.Errorf("charged %d payments, want none", len(provider.charged))
+			}
+		})
+	}
+}
+
+func TestPayVoidsChargesWhenTenderDeclined(t *testing.T) {
+	cashProvider, cardProvider := &fakePaymentProvider{}, &fakePaymentProvider{decline: true}
+	s := &transactionService{
+		repository: &fakeTransactionRepository{transaction: models.Transaction{ID: 1, TotalAmount: 10000}},
+		providers:  PaymentProviders{models.PaymentCash: cashProvider, models.PaymentDebitCard: cardProvider},
+	}
+
+	_, err := s.Pay(1, 1, models.PayRequest{Payments: []models.PaymentRequest{cash(5000), card(5000)}})
+	if !errors.Is(err, models.ErrPaymentDeclined) {
+		t.Fatalf("err = %v, want %v", err, models.ErrPaymentDeclined)
+	}
+	if len(cashProvider.charged) != 1 || len(cashProvider.voided) != 1 {
+		t.Fatalf("cash charged %d, voided %d, want 1, 1", len(cashProvider.charged), len(cashProvider.voided))
+	}
+	if cashProvider.voided[0].Reference != cashProvider.charged[0].Reference {
+		t.Errorf("voided %q, want the charge %q", cashProvider.voided[0].Reference, cashProvider.charged[0].Reference)
+	}
+	if len(cardProvider.voided) != 0 {
+		t.Errorf("voided %d declined card payments, want none", len(cardProvider.voided))
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"log"
+)
+
+type PaymentService interface {
+	CreateQRISPayment(transactionID int) (*models.Payment, error)
+	HandleQRISCallback(body []byte, signature string) (*models.Payment, error)
+}
+
+type paymentService struct {
+	repository repositories.PaymentRepository
+	qris       QRISGateway
+}
+
+// NewPaymentService returns the service for payments confirmed by a
+// gateway. qris may be nil when QRIS is not enabled.
+func NewPaymentService(repo repositories.PaymentRepository, qris QRISGateway) PaymentService {
+	return &paymentService{repository: repo, qris: qris}
+}
+
+// CreateQRISPayment returns a pending QRIS payment for the total of an
+// unpaid transaction. It returns nil, nil if the transaction doesn't exist.
+func (s *paymentService) CreateQRISPayment(transactionID int) (*models.Payment, error) {
+	if s.qris == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrPaymentMethodNotSupported, models.PaymentQRIS)
+	}
+	return s.repository.CreatePending(transactionID, models.PaymentQRIS, func(amount models.Money) (models.Payment, error) {
+		charge, err := s.qris.CreateCharge(transactionID, amount)
+		if err != nil {
+			return models.Payment{}, err
+		}
+		return models.Payment{Reference: charge.Reference, QRPayload: charge.Payload, ExpiresAt: &charge.ExpiresAt}, nil
+	})
+}
+
+// HandleQRISCallback verifies a callback from the QRIS gateway and records
+// the status it reports.
+func (s *paymentService) HandleQRISCallback(body []byte, signature string) (*models.Payment, error) {
+	if s.qris == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrPaymentMethodNotSupported, models.PaymentQRIS)
+	}
+	notification, err := s.qris.ParseCallback(body, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, overpaid, err := s.repository.Complete(models.PaymentQRIS, notification.Reference, notification.Status, notification.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if overpaid {
+		log.Printf("QRIS payment %s of %s paid transaction %d, which was already paid or cancelled; refund it",
+			payment.Reference, payment.Amount, payment.TransactionID)
+	}
+	return payment, nil
+}
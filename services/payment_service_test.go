@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"testing"
+)
+
+// fakePaymentRepository records the gateway statuses it is asked to
+// complete and answers with a canned result.
+type fakePaymentRepository struct {
+	repositories.PaymentRepository
+	completed []QRISNotification
+	err       error
+}
+
+func (r *fakePaymentRepository) Complete(method models.PaymentMethod, reference string, status models.PaymentStatus, amount models.Money) (*models.Payment, bool, error) {
+	r.completed = append(r.completed, QRISNotification{Reference: reference, Status: status, Amount: amount})
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	return &models.Payment{Method: method, Reference: reference, Status: status, Amount: amount}, false, nil
+}
+
+func TestHandleQRISCallback(t *testing.T) {
+	gateway := &MockQRISGateway{Secret: []byte("secret")}
+	body := []byte(`{"reference": "MOCK-1-abc", "status": "paid", "amount": 35000}`)
+
+	tests := []struct {
+		name          string
+		signature     string
+		repositoryErr error
+		wantErr       error
+		wantCompleted int
+	}{
+		{"completes the payment", gateway.Sign(body), nil, nil, 1},
+		{"bad signature never reaches the payment", "00", nil, models.ErrInvalidSignature, 0},
+		{"amount mismatch", gateway.Sign(body), models.ErrPaymentAmountMismatch, models.ErrPaymentAmountMismatch, 1},
+		{"unknown reference", gateway.Sign(body), models.ErrPaymentNotFound, models.ErrPaymentNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePaymentRepository{err: tt.repositoryErr}
+			s := NewPaymentService(repo, gateway)
+
+			payment, err := s.HandleQRISCallback(body, tt.signature)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.completed) != tt.wantCompleted {
+				t.Fatalf("completed %d payments, want %d", len(repo.completed), tt.wantCompleted)
+			}
+			if tt.wantCompleted > 0 {
+				want := QRISNotification{Reference: "MOCK-1-abc", Status: models.PaymentStatusPaid, Amount: 35000}
+				if repo.completed[0] != want {
+					t.Errorf("completed %+v, want %+v", repo.completed[0], want)
+				}
+			}
+			if err == nil && payment.Method != models.PaymentQRIS {
+				t.Errorf("method = %s, want qris", payment.Method)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"kasir-api/models"
+	"slices"
+)
+
+// applyPromotions discounts the lines of t. promotions must be in the order
+// they apply and categoryPaths maps each product in t to its category
+// followed by that category's ancestors. Percentages are rounded with mode.
+// A line is never discounted below zero.
+func applyPromotions(t *models.Transaction, promotions []models.Promotion, categoryPaths map[int][]int, mode models.RoundingMode) {
+	exclusive := make([]bool, len(t.Details))
+	for _, p := range promotions {
+		var eligible []int
+		for i := range t.Details {
+			d := &t.Details[i]
+			if exclusive[i] || (!p.Stackable && d.Discount > 0) || d.Discount >= d.Subtotal {
+				continue
+			}
+			if promotionTargets(p, d, categoryPaths) {
+				eligible = append(eligible, i)
+			}
+		}
+		if len(eligible) == 0 {
+			continue
+		}
+
+		id := p.ID
+		for k, amount := range promotionAmounts(p, t.Details, eligible, mode) {
+			if amount <= 0 {
+				continue
+			}
+			i := eligible[k]
+			d := &t.Details[i]
+			amount = min(amount, d.Subtotal-d.Discount)
+			d.Discount += amount
+			d.Discounts = append(d.Discounts, models.TransactionDiscount{
+				PromotionID:   &id,
+				PromotionName: p.Name,
+				Amount:        amount,
+			})
+			if !p.Stackable {
+				exclusive[i] = true
+			}
+		}
+	}
+}
+
+// promotionTargets reports whether p covers the line d.
+func promotionTargets(p models.Promotion, d *models.TransactionDetail, categoryPaths map[int][]int) bool {
+	switch p.TargetType {
+	case models.PromotionTargetAll:
+		return true
+	case models.PromotionTargetProduct:
+		return p.TargetID != nil && *p.TargetID == d.ProductID
+	case models.PromotionTargetCategory:
+		return p.TargetID != nil && slices.Contains(categoryPaths[d.ProductID], *p.TargetID)
+	}
+	return false
+}
+
+// promotionAmounts returns the discount p gives each of the eligible lines,
+// in the same order. Percentages apply to what is left of a line after
+// earlier promotions.
+func promotionAmounts(p models.Promotion, details []models.TransactionDetail, eligible []int, mode models.RoundingMode) []models.Money {
+	amounts := make([]models.Money, len(eligible))
+	switch p.Type {
+	case models.PromotionPercentage:
+		for k, i := range eligible {
+			remaining := details[i].Subtotal - details[i].Discount
+			amounts[k] = remaining.MulRatio(int64(p.Percent), 100, mode)
+		}
+	case models.PromotionBuyXGetY:
+		if group := p.BuyQuantity + p.FreeQuantity; group > 0 {
+			for k, i := range eligible {
+				amounts[k] = details[i].Price.Times(details[i].Quantity / group * p.FreeQuantity)
+			}
+		}
+	case models.PromotionFixedAmount:
+		remaining := make([]models.Money, len(eligible))
+		var total models.Money
+		for k, i := range eligible {
+			remaining[k] = details[i].Subtotal - details[i].Discount
+			total += remaining[k]
+		}
+		amounts = min(p.Amount, total).Allocate(remaining)
+	}
+	return amounts
+}
+
+// applyTaxes works out the tax and service charge of each line of t on what
+// is left of it after discounts, rounding with mode, and sets the line
+// totals. taxRates maps the products of t to the rate they are taxed at;
+// products missing from it are untaxed. An inclusive tax is the part of the
+// amount that is tax, an exclusive one is added to it. serviceChargeRate is
+// in basis points and isn't taxed.
+func applyTaxes(t *models.Transaction, taxRates map[int]*models.TaxRate, serviceChargeRate int, mode models.RoundingMode) {
+	for i := range t.Details {
+		d := &t.Details[i]
+		net := d.Subtotal - d.Discount
+
+		if rate := taxRates[d.ProductID]; rate != nil {
+			id := rate.ID
+			d.TaxRateID = &id
+			d.TaxName = rate.Name
+			d.TaxRate = rate.Rate
+			d.TaxInclusive = rate.Inclusive
+			if rate.Inclusive {
+				d.Tax = net.MulRatio(int64(rate.Rate), int64(10000+rate.Rate), mode)
+			} else {
+				d.Tax = net.MulRatio(int64(rate.Rate), 10000, mode)
+			}
+		}
+		d.ServiceCharge = net.MulRatio(int64(serviceChargeRate), 10000, mode)
+
+		d.Total = net + d.ServiceCharge
+		if !d.TaxInclusive {
+			d.Total += d.Tax
+		}
+	}
+}
+
+// cashRounding returns the adjustment that rounds an amount paid in cash to
+// a multiple of unit, e.g. Rp100.
+func cashRounding(due, unit models.Money, mode models.RoundingMode) models.Money {
+	return due.RoundTo(unit, mode) - due
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"kasir-api/models"
+	"slices"
+	"testing"
+)
+
+// line returns a checkout line priced the way the repository prices it.
+func line(productID int, price models.Money, quantity int) models.TransactionDetail {
+	subtotal := price.Times(quantity)
+	return models.TransactionDetail{ProductID: productID, Price: price, Quantity: quantity, Subtotal: subtotal, Total: subtotal}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestApplyPromotions(t *testing.T) {
+	percent := func(id, value int, stackable bool) models.Promotion {
+		return models.Promotion{ID: id, Name: "percent", Type: models.PromotionPercentage, Percent: value,
+			TargetType: models.PromotionTargetAll, Stackable: stackable}
+	}
+
+	tests := []struct {
+		name          string
+		details       []models.TransactionDetail
+		promotions    []models.Promotion
+		categoryPaths map[int][]int
+		want          []models.Money
+	}{
+		{
+			name:       "exclusive keeps later exclusive off",
+			details:    []models.TransactionDetail{line(1, 10000, 1)},
+			promotions: []models.Promotion{percent(1, 10, false), percent(2, 20, false)},
+			want:       []models.Money{1000},
+		},
+		{
+			name:       "exclusive keeps later stackable off",
+			details:    []models.TransactionDetail{line(1, 10000, 1)},
+			promotions: []models.Promotion{percent(1, 10, false), percent(2, 10, true)},
+			want:       []models.Money{1000},
+		},
+		{
+			name:       "exclusive skips discounted lines",
+			details:    []models.TransactionDetail{line(1, 10000, 1)},
+			promotions: []models.Promotion{percent(1, 10, true), percent(2, 20, false)},
+			want:       []models.Money{1000},
+		},
+		{
+			name:       "stackable percentages compound",
+			details:    []models.TransactionDetail{line(1, 10000, 1)},
+			promotions: []models.Promotion{percent(1, 10, true), percent(2, 10, true)},
+			want:       []models.Money{1900},
+		},
+		{
+			name:    "buy x get y",
+			details: []models.TransactionDetail{line(1, 5000, 7), line(2, 5000, 2)},
+			promotions: []models.Promotion{{ID: 1, Type: models.PromotionBuyXGetY, BuyQuantity: 2, FreeQuantity: 1,
+				TargetType: models.PromotionTargetAll}},
+			want: []models.Money{10000, 0},
+		},
+		{
+			name:       "fixed amount shared in proportion",
+			details:    []models.TransactionDetail{line(1, 15000, 1), line(2, 25000, 1), line(3, 10000, 1)},
+			promotions: []models.Promotion{{ID: 1, Type: models.PromotionFixedAmount, Amount: 1000, TargetType: models.PromotionTargetAll}},
+			want:       []models.Money{300, 500, 200},
+		},
+		{
+			name:       "fixed amount capped at total",
+			details:    []models.TransactionDetail{line(1, 10000, 1), line(2, 15000, 2)},
+			promotions: []models.Promotion{{ID: 1, Type: models.PromotionFixedAmount, Amount: 50000, TargetType: models.PromotionTargetAll}},
+			want:       []models.Money{10000, 30000},
+		},
+		{
+			name:    "fixed amount capped at what earlier promotions left",
+			details: []models.TransactionDetail{line(1, 10000, 1)},
+			promotions: []models.Promotion{percent(1, 50, true),
+				{ID: 2, Type: models.PromotionFixedAmount, Amount: 8000, TargetType: models.PromotionTargetAll, Stackable: true}},
+			want: []models.Money{10000},
+		},
+		{
+			name:    "product target",
+			details: []models.TransactionDetail{line(1, 10000, 1), line(2, 10000, 1)},
+			promotions: []models.Promotion{{ID: 1, Type: models.PromotionPercentage, Percent: 10,
+				TargetType: models.PromotionTargetProduct, TargetID: intPtr(2)}},
+			want: []models.Money{0, 1000},
+		},
+		{
+			name:    "category target includes subcategories",
+			details: []models.TransactionDetail{line(1, 10000, 1), line(2, 10000, 1)},
+			promotions: []models.Promotion{{ID: 1, Type: models.PromotionPercentage, Percent: 10,
+				TargetType: models.PromotionTargetCategory, TargetID: intPtr(3)}},
+			categoryPaths: map[int][]int{1: {5, 3}, 2: {4}},
+			want:          []models.Money{1000, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := models.Transaction{Details: tt.details}
+			applyPromotions(&transaction, tt.promotions, tt.categoryPaths, models.RoundHalfUp)
+
+			var got []models.Money
+			for _, d := range transaction.Details {
+				var sum models.Money
+				for _, discount := range d.Discounts {
+					sum += discount.Amount
+				}
+				if sum != d.Discount {
+					t.Errorf("product %d: discounts add up to %d, discount is %d", d.ProductID, sum, d.Discount)
+				}
+				got = append(got, d.Discount)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("discounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTaxes(t *testing.T) {
+	ppn := &models.TaxRate{ID: 1, Name: "PPN", Rate: 1100}
+	ppnIncluded := &models.TaxRate{ID: 2, Name: "PPN", Rate: 1100, Inclusive: true}
+	discounted := line(1, 10000, 1)
+	discounted.Discount = 2000
+
+	tests := []struct {
+		name              string
+		detail            models.TransactionDetail
+		taxRates          map[int]*models.TaxRate
+		serviceChargeRate int
+		wantTax           models.Money
+		wantService       models.Money
+		wantTotal         models.Money
+	}{
+		{"exclusive", line(1, 10000, 1), map[int]*models.TaxRate{1: ppn}, 0, 1100, 0, 11100},
+		{"inclusive", line(1, 11100, 1), map[int]*models.TaxRate{1: ppnIncluded}, 0, 1100, 0, 11100},
+		{"inclusive rounded", line(1, 10000, 1), map[int]*models.TaxRate{1: ppnIncluded}, 0, 991, 0, 10000},
+		{"after discount", discounted, map[int]*models.TaxRate{1: ppn}, 0, 880, 0, 8880},
+		{"untaxed", line(1, 10000, 1), map[int]*models.TaxRate{}, 0, 0, 0, 10000},
+		{"service charge is not taxed", line(1, 10000, 1), map[int]*models.TaxRate{1: ppn}, 500, 1100, 500, 11600},
+		{"service charge on inclusive", line(1, 11100, 1), map[int]*models.TaxRate{1: ppnIncluded}, 500, 1100, 555, 11655},
+		{"service charge untaxed product", line(1, 10000, 1), nil, 500, 0, 500, 10500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := models.Transaction{Details: []models.TransactionDetail{tt.detail}}
+			applyTaxes(&transaction, tt.taxRates, tt.serviceChargeRate, models.RoundHalfUp)
+
+			d := transaction.Details[0]
+			if d.Tax != tt.wantTax || d.ServiceCharge != tt.wantService || d.Total != tt.wantTotal {
+				t.Errorf("tax, service charge, total = %d, %d, %d, want %d, %d, %d",
+					d.Tax, d.ServiceCharge, d.Total, tt.wantTax, tt.wantService, tt.wantTotal)
+			}
+			if rate := tt.taxRates[d.ProductID]; rate != nil && (d.TaxRateID == nil || *d.TaxRateID != rate.ID || d.TaxInclusive != rate.Inclusive) {
+				t.Errorf("line not marked with tax rate %d", rate.ID)
+			}
+		})
+	}
+}
+
+func TestCashRounding(t *testing.T) {
+	tests := []struct {
+		name string
+		due  models.Money
+		unit models.Money
+		mode models.RoundingMode
+		want models.Money
+	}{
+		{"down to Rp100", 12340, 100, models.RoundHalfUp, -40},
+		{"up to Rp100", 12350, 100, models.RoundHalfUp, 50},
+		{"tie to even Rp100", 12250, 100, models.RoundHalfEven, -50},
+		{"truncate to Rp100", 12399, 100, models.RoundDown, -99},
+		{"up to Rp500", 12300, 500, models.RoundHalfUp, 200},
+		{"already a multiple", 12500, 500, models.RoundHalfUp, 0},
+		{"disabled", 12345, 0, models.RoundHalfUp, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cashRounding(tt.due, tt.unit, tt.mode); got != tt.want {
+				t.Errorf("cashRounding(%d, %d, %s) = %d, want %d", tt.due, tt.unit, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type ProductCostService interface {
+	GetCostsByProductID(productID, page, pageSize int) ([]models.ProductCost, *utils.PaginationMeta, error)
+	SetCost(productID int, req models.ProductCostRequest) (*models.ProductCost, error)
+}
+
+type productCostService struct {
+	repository repositories.ProductCostRepository
+}
+
+func NewProductCostService(repo repositories.ProductCostRepository) ProductCostService {
+	return &productCostService{repository: repo}
+}
+
+func (s *productCostService) GetCostsByProductID(productID, page, pageSize int) ([]models.ProductCost, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	costs, total, err := s.repository.GetByProductID(productID, pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return costs, meta, nil
+}
+
+// SetCost records a manually entered cost, which becomes the current cost
+// of the product or variant.
+func (s *productCostService) SetCost(productID int, req models.ProductCostRequest) (*models.ProductCost, error) {
+	return s.repository.Create(models.ProductCost{
+		ProductID: productID,
+		VariantID: req.VariantID,
+		UnitCost:  req.UnitCost,
+		Quantity:  req.Quantity,
+		Source:    models.CostSourceManual,
+		Note:      req.Note,
+	})
+}
@@ -7,11 +7,13 @@ import (
 )
 
 type ProductService interface {
-	GetAllProducts(page, pageSize int) ([]models.Product, *utils.PaginationMeta, error)
-	GetProductByID(id int) (*models.Product, error)
+	GetAllProducts(filter models.ProductFilter, page, pageSize int) ([]models.Product, *utils.PaginationMeta, error)
+	GetProductByID(id int, includeDeleted bool) (*models.Product, error)
+	GetProductByBarcode(code string) (*models.Product, error)
 	CreateProduct(product models.Product) (models.Product, error)
 	UpdateProduct(id int, product models.Product) (*models.Product, error)
 	DeleteProduct(id int) error
+	RestoreProduct(id int) (*models.Product, error)
 }
 
 type productService struct {
@@ -22,7 +24,7 @@ func NewProductService(repo repositories.ProductRepository) ProductService {
 	return &productService{repository: repo}
 }
 
-func (s *productService) GetAllProducts(page, pageSize int) ([]models.Product, *utils.PaginationMeta, error) {
+func (s *productService) GetAllProducts(filter models.ProductFilter, page, pageSize int) ([]models.Product, *utils.PaginationMeta, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -31,7 +33,7 @@ func (s *productService) GetAllProducts(page, pageSize int) ([]models.Product, *
 	}
 
 	offset := (page - 1) * pageSize
-	products, total, err := s.repository.GetAll(pageSize, offset)
+	products, total, err := s.repository.GetAll(filter, pageSize, offset)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -50,8 +52,12 @@ func (s *productService) GetAllProducts(page, pageSize int) ([]models.Product, *
 	return products, meta, nil
 }
 
-func (s *productService) GetProductByID(id int) (*models.Product, error) {
-	return s.repository.GetByID(id)
+func (s *productService) GetProductByID(id int, includeDeleted bool) (*models.Product, error) {
+	return s.repository.GetByID(id, includeDeleted)
+}
+
+func (s *productService) GetProductByBarcode(code string) (*models.Product, error) {
+	return s.repository.GetByBarcode(code)
 }
 
 func (s *productService) CreateProduct(product models.Product) (models.Product, error) {
@@ -65,3 +71,7 @@ func (s *productService) UpdateProduct(id int, product models.Product) (*models.
 func (s *productService) DeleteProduct(id int) error {
 	return s.repository.Delete(id)
 }
+
+func (s *productService) RestoreProduct(id int) (*models.Product, error) {
+	return s.repository.Restore(id)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type ProductVariantService interface {
+	GetVariants(productID int) ([]models.ProductVariant, error)
+	CreateVariant(productID int, variant models.ProductVariant) (*models.ProductVariant, error)
+	UpdateVariant(productID, id int, variant models.ProductVariant) (*models.ProductVariant, error)
+	DeleteVariant(productID, id int) error
+}
+
+type productVariantService struct {
+	repository repositories.ProductVariantRepository
+}
+
+func NewProductVariantService(repo repositories.ProductVariantRepository) ProductVariantService {
+	return &productVariantService{repository: repo}
+}
+
+func (s *productVariantService) GetVariants(productID int) ([]models.ProductVariant, error) {
+	return s.repository.GetByProductID(productID)
+}
+
+func (s *productVariantService) CreateVariant(productID int, variant models.ProductVariant) (*models.ProductVariant, error) {
+	variant.ProductID = productID
+	return s.repository.Create(variant)
+}
+
+func (s *productVariantService) UpdateVariant(productID, id int, variant models.ProductVariant) (*models.ProductVariant, error) {
+	return s.repository.Update(productID, id, variant)
+}
+
+func (s *productVariantService) DeleteVariant(productID, id int) error {
+	return s.repository.Delete(productID, id)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type PromotionService interface {
+	GetAllPromotions(page, pageSize int) ([]models.Promotion, *utils.PaginationMeta, error)
+	GetPromotionByID(id int) (*models.Promotion, error)
+	CreatePromotion(promotion models.Promotion) (*models.Promotion, error)
+	UpdatePromotion(id int, promotion models.Promotion) (*models.Promotion, error)
+	DeletePromotion(id int) error
+}
+
+type promotionService struct {
+	repository repositories.PromotionRepository
+}
+
+func NewPromotionService(repo repositories.PromotionRepository) PromotionService {
+	return &promotionService{repository: repo}
+}
+
+func (s *promotionService) GetAllPromotions(page, pageSize int) ([]models.Promotion, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	promotions, total, err := s.repository.GetAll(pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return promotions, meta, nil
+}
+
+func (s *promotionService) GetPromotionByID(id int) (*models.Promotion, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *promotionService) CreatePromotion(promotion models.Promotion) (*models.Promotion, error) {
+	return s.repository.Create(promotion)
+}
+
+func (s *promotionService) UpdatePromotion(id int, promotion models.Promotion) (*models.Promotion, error) {
+	return s.repository.Update(id, promotion)
+}
+
+func (s *promotionService) DeletePromotion(id int) error {
+	return s.repository.Delete(id)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type PurchaseOrderService interface {
+	GetAllPurchaseOrders(status models.PurchaseOrderStatus, page, pageSize int) ([]models.PurchaseOrder, *utils.PaginationMeta, error)
+	GetPurchaseOrderByID(id int) (*models.PurchaseOrder, error)
+	CreatePurchaseOrder(req models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	UpdatePurchaseOrder(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error)
+	OrderPurchaseOrder(id int) (*models.PurchaseOrder, error)
+	CancelPurchaseOrder(id int) (*models.PurchaseOrder, error)
+	ReceivePurchaseOrder(id int, req models.ReceivePurchaseOrderRequest) (*models.PurchaseOrder, error)
+}
+
+type purchaseOrderService struct {
+	repository repositories.PurchaseOrderRepository
+}
+
+func NewPurchaseOrderService(repo repositories.PurchaseOrderRepository) PurchaseOrderService {
+	return &purchaseOrderService{repository: repo}
+}
+
+func (s *purchaseOrderService) GetAllPurchaseOrders(status models.PurchaseOrderStatus, page, pageSize int) ([]models.PurchaseOrder, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	orders, total, err := s.repository.GetAll(status, pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return orders, meta, nil
+}
+
+func (s *purchaseOrderService) GetPurchaseOrderByID(id int) (*models.PurchaseOrder, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *purchaseOrderService) CreatePurchaseOrder(req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	return s.repository.Create(req)
+}
+
+func (s *purchaseOrderService) UpdatePurchaseOrder(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	return s.repository.Update(id, req)
+}
+
+func (s *purchaseOrderService) OrderPurchaseOrder(id int) (*models.PurchaseOrder, error) {
+	po, err := s.repository.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if po == nil {
+		return nil, models.ErrPurchaseOrderNotFound
+	}
+	if len(po.Items) == 0 {
+		return nil, fmt.Errorf("%w: purchase order has no lines", models.ErrInvalidPurchaseOrderTransition)
+	}
+	return s.repository.Order(id)
+}
+
+func (s *purchaseOrderService) CancelPurchaseOrder(id int) (*models.PurchaseOrder, error) {
+	return s.repository.Cancel(id)
+}
+
+func (s *purchaseOrderService) ReceivePurchaseOrder(id int, req models.ReceivePurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	return s.repository.Receive(id, req.Items)
+}
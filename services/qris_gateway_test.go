@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockQRISGatewayParseCallback(t *testing.T) {
+	g := &MockQRISGateway{Secret: []byte("secret")}
+	body := []byte(`{"reference": "MOCK-1-abc", "status": "paid", "amount": 35000}`)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+		want      QRISNotification
+		wantErr   error
+	}{
+		{"paid", body, g.Sign(body), QRISNotification{Reference: "MOCK-1-abc", Status: models.PaymentStatusPaid, Amount: 35000}, nil},
+		{"uppercase hex", body, strings.ToUpper(g.Sign(body)), QRISNotification{Reference: "MOCK-1-abc", Status: models.PaymentStatusPaid, Amount: 35000}, nil},
+		{"expired", []byte(`{"reference": "MOCK-1-abc", "status": "expired"}`), g.Sign([]byte(`{"reference": "MOCK-1-abc", "status": "expired"}`)),
+			QRISNotification{Reference: "MOCK-1-abc", Status: models.PaymentStatusExpired}, nil},
+		{"signed with another secret", body, (&MockQRISGateway{Secret: []byte("other")}).Sign(body), QRISNotification{}, models.ErrInvalidSignature},
+		{"tampered body", []byte(`{"reference": "MOCK-1-abc", "status": "paid", "amount": 1}`), g.Sign(body), QRISNotification{}, models.ErrInvalidSignature},
+		{"bad hex", body, "not-hex", QRISNotification{}, models.ErrInvalidSignature},
+		{"odd length hex", body, g.Sign(body)[1:], QRISNotification{}, models.ErrInvalidSignature},
+		{"missing signature", body, "", QRISNotification{}, models.ErrInvalidSignature},
+		{"unknown status", []byte(`{"reference": "MOCK-1-abc", "status": "refunded"}`), g.Sign([]byte(`{"reference": "MOCK-1-abc", "status": "refunded"}`)),
+			QRISNotification{}, models.ErrInvalidCallback},
+		{"pending status", []byte(`{"reference": "MOCK-1-abc", "status": "pending"}`), g.Sign([]byte(`{"reference": "MOCK-1-abc", "status": "pending"}`)),
+			QRISNotification{}, models.ErrInvalidCallback},
+		{"not json", []byte("paid"), g.Sign([]byte("paid")), QRISNotification{}, models.ErrInvalidCallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.ParseCallback(tt.body, tt.signature)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("notification = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCRC16CCITT(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint16
+	}{
+		{"", 0xFFFF},
+		{"123456789", 0x29B1},
+		{"A", 0xB915},
+	}
+
+	for _, tt := range tests {
+		if got := crc16CCITT([]byte(tt.data)); got != tt.want {
+			t.Errorf("crc16CCITT(%q) = %04X, want %04X", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestMockQRISGatewayCreateCharge(t *testing.T) {
+	g := &MockQRISGateway{Secret: []byte("secret"), Expiry: 15 * time.Minute, MerchantName: "Kasir", MerchantCity: "Jakarta"}
+
+	charge, err := g.CreateCharge(7, 35000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := charge.Payload
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != "6304" {
+		t.Fatalf("payload %q does not end with a CRC field", payload)
+	}
+	body, crc := payload[:len(payload)-4], payload[len(payload)-4:]
+	if want := fmt.Sprintf("%04X", crc16CCITT([]byte(body))); crc != want {
+		t.Errorf("CRC = %s, want %s", crc, want)
+	}
+	for _, field := range []string{emvField("54", "35000"), emvField("59", "Kasir"), emvField("60", "Jakarta"), charge.Reference} {
+		if !strings.Contains(payload, field) {
+			t.Errorf("payload %q is missing %q", payload, field)
+		}
+	}
+	if !strings.HasPrefix(charge.Reference, "MOCK-7-") {
+		t.Errorf("reference = %q, want it to name the transaction", charge.Reference)
+	}
+	if time.Until(charge.ExpiresAt) <= 14*time.Minute {
+		t.Errorf("expires at %s, want about 15 minutes from now", charge.ExpiresAt)
+	}
+}
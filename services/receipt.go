@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"kasir-api/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// receiptLine is one printed line of a receipt. Lines are laid out to the
+// columns of the paper; large lines print at double width and height, so
+// they hold half as many characters.
+type receiptLine struct {
+	Text   string
+	Center bool
+	Bold   bool
+	Large  bool
+}
+
+type receiptLayout struct {
+	columns int
+	lines   []receiptLine
+}
+
+// center adds s centered, wrapped at the width of the line.
+func (l *receiptLayout) center(s string, bold, large bool) {
+	width := l.columns
+	if large {
+		width /= 2
+	}
+	for _, text := range wrap(s, width) {
+		l.lines = append(l.lines, receiptLine{Text: text, Center: true, Bold: bold, Large: large})
+	}
+}
+
+// left adds s aligned left, wrapped at the width of the paper.
+func (l *receiptLayout) left(s string) {
+	for _, text := range wrap(s, l.columns) {
+		l.lines = append(l.lines, receiptLine{Text: text})
+	}
+}
+
+// pair adds a label on the left and an amount on the right of the same
+// line, or the label on lines of its own when both don't fit.
+func (l *receiptLayout) pair(label, amount string, bold bool) {
+	labels := wrap(label, l.columns)
+	last := labels[len(labels)-1]
+	gap := l.columns - utf8.RuneCountInString(last) - utf8.RuneCountInString(amount)
+	if gap < 1 {
+		labels = append(labels, "")
+		gap = l.columns - utf8.RuneCountInString(amount)
+	}
+	for _, text := range labels[:len(labels)-1] {
+		l.lines = append(l.lines, receiptLine{Text: text, Bold: bold})
+	}
+	text := labels[len(labels)-1] + strings.Repeat(" ", max(gap, 0)) + amount
+	l.lines = append(l.lines, receiptLine{Text: text, Bold: bold})
+}
+
+func (l *receiptLayout) rule() {
+	l.lines = append(l.lines, receiptLine{Text: strings.Repeat("-", l.columns)})
+}
+
+// wrap breaks s into lines of at most width characters at spaces, splitting
+// words longer than a line. Leading spaces indent every line. It always
+// returns at least one line.
+func wrap(s string, width int) []string {
+	trimmed := strings.TrimLeft(s, " ")
+	if indent := s[:len(s)-len(trimmed)]; indent != "" && len(indent) < width {
+		lines := wrap(trimmed, width-len(indent))
+		for i := range lines {
+			lines[i] = indent + lines[i]
+		}
+		return lines
+	}
+
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// formatRate writes a rate in basis points as a percentage, e.g. 11% or
+// 2.5%.
+func formatRate(basisPoints int) string {
+	s := strconv.Itoa(basisPoints / 100)
+	if frac := basisPoints % 100; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%02d", frac), "0")
+	}
+	return s + "%"
+}
+
+var paymentMethodLabels = map[models.PaymentMethod]string{
+	models.PaymentCash:       "Cash",
+	models.PaymentDebitCard:  "Debit card",
+	models.PaymentCreditCard: "Credit card",
+	models.PaymentEWallet:    "E-wallet",
+	models.PaymentQRIS:       "QRIS",
+}
+
+// layoutReceipt lays out the receipt of t: the store header, the lines with
+// their discounts, the totals and taxes, and the tenders with change.
+// Inclusive taxes are already in the prices, so they are listed after the
+// total for information.
+func layoutReceipt(t *models.Transaction, cashier string, store models.StoreInfo, columns int) []receiptLine {
+	l := &receiptLayout{columns: columns}
+
+	l.center(store.Name, true, true)
+	if store.Address != "" {
+		l.center(store.Address, false, false)
+	}
+	if store.Phone != "" {
+		l.center(store.Phone, false, false)
+	}
+	l.rule()
+	l.pair(fmt.Sprintf("No. %d", t.ID), t.CreatedAt.In(store.Location).Format("02/01/2006 15:04"), false)
+	if cashier != "" {
+		l.left("Cashier: " + cashier)
+	}
+	l.rule()
+
+	for _, d := range t.Details {
+		name := d.ProductName
+		if d.VariantName != "" {
+			name += " " + d.VariantName
+		}
+		l.left(name)
+		l.pair(fmt.Sprintf("  %d x %s", d.Quantity, d.Price), d.Subtotal.String(), false)
+		for _, discount := range d.Discounts {
+			l.pair("  "+discount.PromotionName, (-discount.Amount).String(), false)
+		}
+	}
+	l.rule()
+
+	l.pair("Subtotal", t.Subtotal.String(), false)
+	if t.DiscountTotal != 0 {
+		l.pair("Discount", (-t.DiscountTotal).String(), false)
+	}
+	if t.ServiceCharge != 0 {
+		l.pair("Service charge", t.ServiceCharge.String(), false)
+	}
+	for _, tax := range t.Taxes {
+		if !tax.Inclusive {
+			l.pair(tax.Name+" "+formatRate(tax.Rate), tax.Amount.String(), false)
+		}
+	}
+	l.pair("TOTAL", t.TotalAmount.String(), true)
+	for _, tax := range t.Taxes {
+		if tax.Inclusive {
+			l.pair("Incl. "+tax.Name+" "+formatRate(tax.Rate), tax.Amount.String(), false)
+		}
+	}
+	l.rule()
+
+	if t.PaymentStatus == models.PaymentStatusPaid {
+		if t.Rounding != 0 {
+			l.pair("Cash rounding", t.Rounding.String(), false)
+		}
+		for _, p := range t.Payments {
+			if p.Status != models.PaymentStatusPaid {
+				continue
+			}
+			l.pair(paymentMethodLabels[p.Method], p.Amount.String(), false)
+		}
+		l.pair("Change", t.Change.String(), false)
+	} else {
+		l.center(strings.ToUpper(string(t.PaymentStatus)), true, false)
+	}
+
+	if store.Footer != "" {
+		l.rule()
+		l.center(store.Footer, false, false)
+	}
+	return l.lines
+}
+
+// renderText writes the lines as plain text, centering lines by padding.
+func renderText(lines []receiptLine, columns int) []byte {
+	var b bytes.Buffer
+	for _, line := range lines {
+		if line.Center {
+			b.WriteString(strings.Repeat(" ", max(columns-utf8.RuneCountInString(line.Text), 0)/2))
+		}
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// ESC/POS commands.
+var (
+	escInit        = []byte{0x1B, '@'}
+	escAlignLeft   = []byte{0x1B, 'a', 0}
+	escAlignCenter = []byte{0x1B, 'a', 1}
+	escBoldOn      = []byte{0x1B, 'E', 1}
+	escBoldOff     = []byte{0x1B, 'E', 0}
+	escDoubleSize  = []byte{0x1D, '!', 0x11}
+	escNormalSize  = []byte{0x1D, '!', 0x00}
+	escFeedAndCut  = []byte{0x1B, 'd', 4, 0x1D, 'V', 66, 0}
+)
+
+// renderESCPOS writes the lines as ESC/POS commands: the printer centers
+// lines and prints bold and double size ones, then the paper is fed and
+// cut. Printers use a single byte code page, so characters outside ASCII
+// print as '?'.
+func renderESCPOS(lines []receiptLine) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+	for _, line := range lines {
+		if line.Center {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if line.Bold {
+			b.Write(escBoldOn)
+		}
+		if line.Large {
+			b.Write(escDoubleSize)
+		}
+		b.WriteString(toASCII(line.Text))
+		b.WriteByte('\n')
+		if line.Large {
+			b.Write(escNormalSize)
+		}
+		if line.Bold {
+			b.Write(escBoldOff)
+		}
+	}
+	b.Write(escFeedAndCut)
+	return b.Bytes()
+}
+
+// renderPDF writes the lines as a single page PDF as wide as the paper and
+// as long as the receipt, in Courier sized so a line of columns characters
+// fills the printable width.
+func renderPDF(lines []receiptLine, width models.PaperWidth) []byte {
+	const mm = 72 / 25.4
+	const charWidth = 0.6 // of the font size, for Courier
+	columns := width.Columns()
+	margin := 3 * mm
+	pageWidth := float64(width) * mm
+	fontSize := (pageWidth - 2*margin) / (float64(columns) * charWidth)
+	leading := fontSize * 1.25
+
+	pageHeight := 2 * margin
+	for _, line := range lines {
+		pageHeight += lineScale(line) * leading
+	}
+
+	var content bytes.Buffer
+	y := pageHeight - margin
+	for _, line := range lines {
+		scale := lineScale(line)
+		y -= scale * leading
+		text := toASCII(line.Text)
+		x := margin
+		if line.Center {
+			x += (float64(columns) - scale*float64(len(text))) * charWidth * fontSize / 2
+		}
+		font := "F1"
+		if line.Bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+			font, pdfNumber(scale*fontSize), pdfNumber(x), pdfNumber(y+(leading-fontSize)/2), pdfEscape(text))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>",
+			pdfNumber(pageWidth), pdfNumber(pageHeight)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func lineScale(line receiptLine) float64 {
+	if line.Large {
+		return 2
+	}
+	return 1
+}
+
+func pdfNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// toASCII replaces characters outside printable ASCII with '?'.
+func toASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return '?'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type ReceiptService interface {
+	RenderReceipt(transactionID int, format models.ReceiptFormat, width models.PaperWidth) ([]byte, error)
+}
+
+type receiptService struct {
+	transactions repositories.TransactionRepository
+	shifts       repositories.ShiftRepository
+	store        models.StoreInfo
+	width        models.PaperWidth
+}
+
+// NewReceiptService returns a service printing receipts with the store's
+// details, on paper of the given width unless a request asks for another.
+func NewReceiptService(transactions repositories.TransactionRepository, shifts repositories.ShiftRepository,
+	store models.StoreInfo, width models.PaperWidth) ReceiptService {
+	return &receiptService{transactions: transactions, shifts: shifts, store: store, width: width}
+}
+
+// RenderReceipt renders the receipt of a transaction in format. A zero width
+// uses the default paper width. It returns nil, nil if the transaction
+// doesn't exist.
+func (s *receiptService) RenderReceipt(transactionID int, format models.ReceiptFormat, width models.PaperWidth) ([]byte, error) {
+	t, err := s.transactions.GetByID(transactionID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+
+	cashier := ""
+	if t.ShiftID != nil {
+		shift, err := s.shifts.GetByID(*t.ShiftID)
+		if err != nil {
+			return nil, err
+		}
+		if shift != nil {
+			cashier = shift.Username
+		}
+	}
+
+	if width == 0 {
+		width = s.width
+	}
+	lines := layoutReceipt(t, cashier, s.store, width.Columns())
+	switch format {
+	case models.ReceiptESCPOS:
+		return renderESCPOS(lines), nil
+	case models.ReceiptPDF:
+		return renderPDF(lines, width), nil
+	default:
+		return renderText(lines, width.Columns()), nil
+	}
+}
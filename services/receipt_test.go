@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"kasir-api/models"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  []string
+	}{
+		{"fits", "Kopi Susu", 32, []string{"Kopi Susu"}},
+		{"exactly the width", "Kopi Susu", 9, []string{"Kopi Susu"}},
+		{"breaks at spaces", "Es Teh Manis Jumbo Dingin", 10, []string{"Es Teh", "Manis", "Jumbo", "Dingin"}},
+		{"splits long words", "Supercalifragilistic", 8, []string{"Supercal", "ifragili", "stic"}},
+		{"long word after short one", "Es Supercalifragilistic", 8, []string{"Es", "Supercal", "ifragili", "stic"}},
+		{"indents every line", "  Diskon Akhir Pekan", 10, []string{"  Diskon", "  Akhir", "  Pekan"}},
+		{"counts characters, not bytes", "Kopi Gula Aren ☕☕", 14, []string{"Kopi Gula Aren", "☕☕"}},
+		{"collapses spaces", "Roti   Bakar", 32, []string{"Roti Bakar"}},
+		{"empty", "", 32, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrap(tt.s, tt.width); !slices.Equal(got, tt.want) {
+				t.Errorf("wrap(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiptLayoutPair(t *testing.T) {
+	tests := []struct {
+		name   string
+		label  string
+		amount string
+		want   []string
+	}{
+		{"amount on the right", "Subtotal", "Rp15.000", []string{"Subtotal    Rp15.000"}},
+		{"fills the line", "Service charge", "Rp150", []string{"Service charge Rp150"}},
+		{"amount on a line of its own", "Service charge", "Rp1.500", []string{"Service charge", "             Rp1.500"}},
+		{"wrapped label", "Diskon Akhir Pekan Member", "-Rp500", []string{"Diskon Akhir Pekan", "Member        -Rp500"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &receiptLayout{columns: 20}
+			l.pair(tt.label, tt.amount, true)
+
+			var got []string
+			for _, line := range l.lines {
+				if !line.Bold {
+					t.Errorf("line %q is not bold", line.Text)
+				}
+				got = append(got, line.Text)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pair(%q, %q) = %q, want %q", tt.label, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+// receiptTransaction is a paid sale of a long-named product with a
+// discount and an exclusive tax, settled in cash with rounding.
+func receiptTransaction() *models.Transaction {
+	return &models.Transaction{
+		ID:            42,
+		Subtotal:      30000,
+		DiscountTotal: 3000,
+		TotalAmount:   29970,
+		Rounding:      30,
+		Change:        0,
+		PaymentStatus: models.PaymentStatusPaid,
+		CreatedAt:     time.Date(2026, 1, 31, 14, 5, 0, 0, time.UTC),
+		Details: []models.TransactionDetail{{
+			ProductName: "Kopi Susu Gula Aren Extra Large Dengan Es Batu",
+			VariantName: "Panas",
+			Quantity:    2,
+			Price:       15000,
+			Subtotal:    30000,
+			Discounts:   []models.TransactionDiscount{{PromotionName: "Diskon Akhir Pekan Spesial Member", Amount: 3000}},
+		}},
+		Taxes: []models.TransactionTax{{Name: "PPN", Rate: 1100, Amount: 2970}},
+		Payments: []models.Payment{
+			{Method: models.PaymentQRIS, Amount: 10000, Status: models.PaymentStatusExpired},
+			{Method: models.PaymentCash, Amount: 30000, Status: models.PaymentStatusPaid},
+		},
+	}
+}
+
+var receiptStore = models.StoreInfo{
+	Name:     "Toko Kelontong Sumber Rejeki Makmur",
+	Address:  "Jl. Merdeka No. 17, Bandung",
+	Phone:    "022-1234567",
+	Footer:   "Terima kasih atas kunjungan Anda",
+	Location: time.UTC,
+}
+
+// pairText is a label and an amount laid out on one line of columns.
+func pairText(label, amount string, columns int) string {
+	return label + strings.Repeat(" ", columns-utf8.RuneCountInString(label)-utf8.RuneCountInString(amount)) + amount
+}
+
+func TestLayoutReceiptFitsPaper(t *testing.T) {
+	for _, width := range []models.PaperWidth{models.Paper58mm, models.Paper80mm} {
+		t.Run(fmt.Sprintf("%dmm", width), func(t *testing.T) {
+			columns := width.Columns()
+			for _, line := range layoutReceipt(receiptTransaction(), "kasir1", receiptStore, columns) {
+				limit := columns
+				if line.Large {
+					limit /= 2
+				}
+				if n := utf8.RuneCountInString(line.Text); n > limit {
+					t.Errorf("line %q is %d characters, want at most %d", line.Text, n, limit)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutReceiptTotals(t *testing.T) {
+	const columns = 32
+	unpaid := receiptTransaction()
+	unpaid.PaymentStatus = models.PaymentStatusUnpaid
+	unpaid.Rounding = 0
+	unpaid.Payments = nil
+	inclusive := receiptTransaction()
+	inclusive.Taxes[0].Inclusive = true
+
+	tests := []struct {
+		name        string
+		transaction *models.Transaction
+		want        []string
+		notWant     []string
+	}{
+		{
+			name:        "cash rounding before the tenders",
+			transaction: receiptTransaction(),
+			want: []string{
+				pairText("Subtotal", "Rp30.000", columns),
+				pairText("Discount", "-Rp3.000", columns),
+				pairText("PPN 11%", "Rp2.970", columns),
+				pairText("TOTAL", "Rp29.970", columns),
+				pairText("Cash rounding", "Rp30", columns),
+				pairText("Cash", "Rp30.000", columns),
+				pairText("Change", "Rp0", columns),
+			},
+			notWant: []string{"QRIS"},
+		},
+		{
+			name:        "inclusive tax after the total",
+			transaction: inclusive,
+			want: []string{
+				pairText("TOTAL", "Rp29.970", columns),
+				pairText("Incl. PPN 11%", "Rp2.970", columns),
+			},
+			notWant: []string{pairText("PPN 11%", "Rp2.970", columns)},
+		},
+		{
+			name:        "unpaid",
+			transaction: unpaid,
+			want:        []string{pairText("TOTAL", "Rp29.970", columns), "UNPAID"},
+			notWant:     []string{"Cash rounding", "Change"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := layoutReceipt(tt.transaction, "", receiptStore, columns)
+			var texts []string
+			for _, line := range lines {
+				texts = append(texts, line.Text)
+			}
+
+			// The wanted lines appear in order.
+			i := 0
+			for _, text := range texts {
+				if i < len(tt.want) && text == tt.want[i] {
+					i++
+				}
+			}
+			if i < len(tt.want) {
+				t.Errorf("receipt is missing %q after the earlier lines:\n%s", tt.want[i], strings.Join(texts, "\n"))
+			}
+			for _, text := range texts {
+				for _, notWant := range tt.notWant {
+					if strings.HasPrefix(text, notWant) {
+						t.Errorf("receipt has %q, want no %q", text, notWant)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderESCPOS(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []receiptLine
+		want  []byte
+	}{
+		{
+			name:  "left",
+			lines: []receiptLine{{Text: "Kopi"}},
+			want:  []byte("\x1b@\x1ba\x00Kopi\n\x1bd\x04\x1dVB\x00"),
+		},
+		{
+			name:  "centered bold double size",
+			lines: []receiptLine{{Text: "Toko", Center: true, Bold: true, Large: true}},
+			want:  []byte("\x1b@\x1ba\x01\x1bE\x01\x1d!\x11Toko\n\x1d!\x00\x1bE\x00\x1bd\x04\x1dVB\x00"),
+		},
+		{
+			name:  "non-ASCII replaced",
+			lines: []receiptLine{{Text: "Café ☕"}},
+			want:  []byte("\x1b@\x1ba\x00Caf? ?\n\x1bd\x04\x1dVB\x00"),
+		},
+		{
+			name: "init and cut only",
+			want: []byte("\x1b@\x1bd\x04\x1dVB\x00"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderESCPOS(tt.lines)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("renderESCPOS = %q, want %q", got, tt.want)
+			}
+			if !bytes.HasPrefix(got, escInit) || !bytes.HasSuffix(got, escFeedAndCut) {
+				t.Errorf("output %q does not start with init and end with feed and cut", got)
+			}
+		})
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	objectEntry := regexp.MustCompile(`(\d{10}) 00000 n `)
+
+	for _, width := range []models.PaperWidth{models.Paper58mm, models.Paper80mm} {
+		t.Run(fmt.Sprintf("%dmm", width), func(t *testing.T) {
+			lines := layoutReceipt(receiptTransaction(), "kasir1", receiptStore, width.Columns())
+			pdf := renderPDF(lines, width)
+
+			if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+				t.Fatalf("not a PDF file: %q...", pdf[:min(len(pdf), 16)])
+			}
+
+			i := bytes.LastIndex(pdf, []byte("startxref\n"))
+			if i < 0 {
+				t.Fatal("no startxref")
+			}
+			field, _, _ := strings.Cut(string(pdf[i+len("startxref\n"):]), "\n")
+			xref, err := strconv.Atoi(field)
+			if err != nil || xref < 0 || xref >= len(pdf) {
+				t.Fatalf("startxref %q is not an offset in the file", field)
+			}
+			if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+				t.Fatalf("startxref %d points at %q, want the xref table", xref, pdf[xref:min(len(pdf), xref+8)])
+			}
+
+			entries := objectEntry.FindAllStringSubmatch(string(pdf[xref:]), -1)
+			if len(entries) != 6 {
+				t.Fatalf("xref has %d objects, want 6", len(entries))
+			}
+			for n, entry := range entries {
+				offset, _ := strconv.Atoi(entry[1])
+				if want := fmt.Sprintf("%d 0 obj\n", n+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+					t.Errorf("object %d at offset %d starts with %q", n+1, offset, pdf[offset:min(len(pdf), offset+8)])
+				}
+			}
+		})
+	}
+}
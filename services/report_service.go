@@ -0,0 +1,155 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+	"time"
+)
+
+type ReportService interface {
+	SalesReport(req models.SalesReportRequest) ([]models.SalesReportRow, *models.SalesReportTotals, error)
+	TopProducts(req models.TopProductsRequest) ([]models.TopProduct, *models.TopProductsMeta, error)
+	SlowMovers(req models.SlowMoversRequest, page, pageSize int) ([]models.SlowMover, *utils.PaginationMeta, error)
+	MarginReport(req models.MarginReportRequest) ([]models.MarginReportRow, *models.MarginReportTotals, error)
+}
+
+type reportService struct {
+	repository repositories.ReportRepository
+	location   *time.Location
+}
+
+// NewReportService returns a service reporting days and hours in the store
+// timezone.
+func NewReportService(repo repositories.ReportRepository, location *time.Location) ReportService {
+	return &reportService{repository: repo, location: location}
+}
+
+// SalesReport aggregates the paid sales of the days from req.From to req.To
+// in the store timezone. Only the dates of From and To are used; a zero
+// date is today.
+func (s *reportService) SalesReport(req models.SalesReportRequest) ([]models.SalesReportRow, *models.SalesReportTotals, error) {
+	from, to := s.period(req.From, req.To)
+	rows, err := s.repository.Sales(from, to, s.location.String(), req.GroupBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	totals, err := s.repository.SalesTotals(from, to, s.location.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	totals.From = from.Format(time.DateOnly)
+	totals.To = to.AddDate(0, 0, -1).Format(time.DateOnly)
+	totals.Timezone = s.location.String()
+	totals.GroupBy = req.GroupBy
+	return rows, &totals, nil
+}
+
+// TopProducts ranks the products sold on the days from req.From to req.To
+// in the store timezone, like SalesReport. A Limit below 1 is 10.
+func (s *reportService) TopProducts(req models.TopProductsRequest) ([]models.TopProduct, *models.TopProductsMeta, error) {
+	if req.Limit < 1 {
+		req.Limit = 10
+	}
+	from, to := s.period(req.From, req.To)
+	products, err := s.repository.TopProducts(from, to, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta := &models.TopProductsMeta{
+		From:        from.Format(time.DateOnly),
+		To:          to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Timezone:    s.location.String(),
+		By:          req.By,
+		Limit:       req.Limit,
+		PerCategory: req.PerCategory,
+	}
+	return products, meta, nil
+}
+
+// SlowMovers lists the products not sold since the start of the day
+// req.Days days ago in the store timezone, i.e. neither today nor on the
+// req.Days days before.
+func (s *reportService) SlowMovers(req models.SlowMoversRequest, page, pageSize int) ([]models.SlowMover, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	since := s.startOfDay(time.Time{}, time.Now().In(s.location)).AddDate(0, 0, -req.Days)
+	offset := (page - 1) * pageSize
+	products, total, err := s.repository.SlowMovers(since, req.CategoryID, pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+	return products, meta, nil
+}
+
+// MarginReport works out the gross margin of the paid sales of the days
+// from req.From to req.To in the store timezone, like SalesReport.
+func (s *reportService) MarginReport(req models.MarginReportRequest) ([]models.MarginReportRow, *models.MarginReportTotals, error) {
+	from, to := s.period(req.From, req.To)
+	rows, err := s.repository.Margin(from, to, s.location.String(), req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals := &models.MarginReportTotals{
+		From:     from.Format(time.DateOnly),
+		To:       to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Timezone: s.location.String(),
+		GroupBy:  req.GroupBy,
+		Method:   req.Method,
+	}
+	for i := range rows {
+		row := &rows[i]
+		row.GrossProfit, row.Margin = grossMargin(row.Revenue-row.UncostedRevenue, row.COGS)
+		totals.Quantity += row.Quantity
+		totals.Revenue += row.Revenue
+		totals.COGS += row.COGS
+		totals.UncostedQuantity += row.UncostedQuantity
+		totals.UncostedRevenue += row.UncostedRevenue
+	}
+	totals.GrossProfit, totals.Margin = grossMargin(totals.Revenue-totals.UncostedRevenue, totals.COGS)
+	return rows, totals, nil
+}
+
+// grossMargin returns the gross profit on revenue and its margin in basis
+// points, or a nil margin without revenue.
+func grossMargin(revenue, cogs models.Money) (models.Money, *int) {
+	profit := revenue - cogs
+	if revenue == 0 {
+		return profit, nil
+	}
+	margin := int(profit.MulRatio(10000, int64(revenue), models.RoundHalfUp))
+	return profit, &margin
+}
+
+// period returns the start of the day of from and the end of the day of to
+// in the store timezone, as an exclusive bound. Zero dates are today.
+func (s *reportService) period(from, to time.Time) (time.Time, time.Time) {
+	today := time.Now().In(s.location)
+	return s.startOfDay(from, today), s.startOfDay(to, today).AddDate(0, 0, 1)
+}
+
+// startOfDay returns midnight in the store timezone of the date of d, or of
+// today if d is zero.
+func (s *reportService) startOfDay(d, today time.Time) time.Time {
+	if d.IsZero() {
+		d = today
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, s.location)
+}
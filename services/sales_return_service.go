@@ -0,0 +1,43 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type SalesReturnService interface {
+	CreateReturn(userID, transactionID int, req models.ReturnRequest) (*models.SalesReturn, error)
+	GetReturnsByTransactionID(transactionID int) ([]models.SalesReturn, error)
+}
+
+type salesReturnService struct {
+	repository repositories.SalesReturnRepository
+}
+
+func NewSalesReturnService(repo repositories.SalesReturnRepository) SalesReturnService {
+	return &salesReturnService{repository: repo}
+}
+
+// CreateReturn records a return against a transaction as part of the
+// user's shift.
+func (s *salesReturnService) CreateReturn(userID, transactionID int, req models.ReturnRequest) (*models.SalesReturn, error) {
+	// Merge duplicate lines so the sold quantity is checked against the
+	// total being returned.
+	var items []models.ReturnItemRequest
+	index := make(map[int]int)
+	for _, item := range req.Items {
+		if i, ok := index[item.TransactionDetailID]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.TransactionDetailID] = len(items)
+		items = append(items, item)
+	}
+	req.Items = items
+
+	return s.repository.Create(userID, transactionID, req)
+}
+
+func (s *salesReturnService) GetReturnsByTransactionID(transactionID int) ([]models.SalesReturn, error) {
+	return s.repository.GetByTransactionID(transactionID)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type ShiftService interface {
+	GetAllShifts(page, pageSize int) ([]models.Shift, *utils.PaginationMeta, error)
+	GetShiftByID(id int) (*models.Shift, error)
+	GetOpenShift(userID int) (*models.Shift, error)
+	OpenShift(userID int, req models.OpenShiftRequest) (*models.Shift, error)
+	AddCashMovement(userID int, req models.CashMovementRequest) (*models.Shift, error)
+	CloseShift(userID int, req models.CloseShiftRequest) (*models.Shift, error)
+}
+
+type shiftService struct {
+	repository repositories.ShiftRepository
+}
+
+func NewShiftService(repo repositories.ShiftRepository) ShiftService {
+	return &shiftService{repository: repo}
+}
+
+func (s *shiftService) GetAllShifts(page, pageSize int) ([]models.Shift, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	shifts, total, err := s.repository.GetAll(pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return shifts, meta, nil
+}
+
+func (s *shiftService) GetShiftByID(id int) (*models.Shift, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *shiftService) GetOpenShift(userID int) (*models.Shift, error) {
+	return s.repository.GetOpen(userID)
+}
+
+func (s *shiftService) OpenShift(userID int, req models.OpenShiftRequest) (*models.Shift, error) {
+	return s.repository.Open(userID, req)
+}
+
+func (s *shiftService) AddCashMovement(userID int, req models.CashMovementRequest) (*models.Shift, error) {
+	return s.repository.AddCashMovement(userID, req)
+}
+
+func (s *shiftService) CloseShift(userID int, req models.CloseShiftRequest) (*models.Shift, error) {
+	return s.repository.Close(userID, req)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type StockCountService interface {
+	GetAllStockCounts(page, pageSize int) ([]models.StockCount, *utils.PaginationMeta, error)
+	GetStockCountByID(id int) (*models.StockCount, error)
+	OpenStockCount(req models.OpenStockCountRequest) (*models.StockCount, error)
+	SubmitCounts(id int, req models.SubmitStockCountRequest) (*models.StockCount, error)
+	ApproveStockCount(id int) (*models.StockCount, error)
+	CancelStockCount(id int) (*models.StockCount, error)
+}
+
+type stockCountService struct {
+	repository repositories.StockCountRepository
+}
+
+func NewStockCountService(repo repositories.StockCountRepository) StockCountService {
+	return &stockCountService{repository: repo}
+}
+
+func (s *stockCountService) GetAllStockCounts(page, pageSize int) ([]models.StockCount, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	counts, total, err := s.repository.GetAll(pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return counts, meta, nil
+}
+
+func (s *stockCountService) GetStockCountByID(id int) (*models.StockCount, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *stockCountService) OpenStockCount(req models.OpenStockCountRequest) (*models.StockCount, error) {
+	return s.repository.Open(req)
+}
+
+func (s *stockCountService) SubmitCounts(id int, req models.SubmitStockCountRequest) (*models.StockCount, error) {
+	// A product listed twice keeps its last count.
+	var entries []models.StockCountEntry
+	index := make(map[int]int)
+	for _, entry := range req.Items {
+		if i, ok := index[entry.ProductID]; ok {
+			entries[i] = entry
+			continue
+		}
+		index[entry.ProductID] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	return s.repository.SubmitCounts(id, entries)
+}
+
+func (s *stockCountService) ApproveStockCount(id int) (*models.StockCount, error) {
+	return s.repository.Approve(id)
+}
+
+func (s *stockCountService) CancelStockCount(id int) (*models.StockCount, error) {
+	return s.repository.Cancel(id)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type StockMovementService interface {
+	GetMovementsByProductID(productID, page, pageSize int) ([]models.StockMovement, *utils.PaginationMeta, error)
+	AdjustStock(productID int, req models.StockAdjustmentRequest) (*models.StockMovement, error)
+	GetDiscrepancies() ([]models.StockDiscrepancy, error)
+}
+
+type stockMovementService struct {
+	repository repositories.StockMovementRepository
+}
+
+func NewStockMovementService(repo repositories.StockMovementRepository) StockMovementService {
+	return &stockMovementService{repository: repo}
+}
+
+func (s *stockMovementService) GetMovementsByProductID(productID, page, pageSize int) ([]models.StockMovement, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	movements, total, err := s.repository.GetByProductID(productID, pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return movements, meta, nil
+}
+
+func (s *stockMovementService) AdjustStock(productID int, req models.StockAdjustmentRequest) (*models.StockMovement, error) {
+	return s.repository.Create(models.StockMovement{
+		ProductID: productID,
+		VariantID: req.VariantID,
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		Note:      req.Note,
+	})
+}
+
+func (s *stockMovementService) GetDiscrepancies() ([]models.StockDiscrepancy, error) {
+	return s.repository.GetDiscrepancies()
+}
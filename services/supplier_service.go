@@ -0,0 +1,67 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type SupplierService interface {
+	GetAllSuppliers(page, pageSize int) ([]models.Supplier, *utils.PaginationMeta, error)
+	GetSupplierByID(id int) (*models.Supplier, error)
+	CreateSupplier(supplier models.Supplier) (models.Supplier, error)
+	UpdateSupplier(id int, supplier models.Supplier) (*models.Supplier, error)
+	DeleteSupplier(id int) error
+}
+
+type supplierService struct {
+	repository repositories.SupplierRepository
+}
+
+func NewSupplierService(repo repositories.SupplierRepository) SupplierService {
+	return &supplierService{repository: repo}
+}
+
+func (s *supplierService) GetAllSuppliers(page, pageSize int) ([]models.Supplier, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	suppliers, total, err := s.repository.GetAll(pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return suppliers, meta, nil
+}
+
+func (s *supplierService) GetSupplierByID(id int) (*models.Supplier, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *supplierService) CreateSupplier(supplier models.Supplier) (models.Supplier, error) {
+	return s.repository.Create(supplier)
+}
+
+func (s *supplierService) UpdateSupplier(id int, supplier models.Supplier) (*models.Supplier, error) {
+	return s.repository.Update(id, supplier)
+}
+
+func (s *supplierService) DeleteSupplier(id int) error {
+	return s.repository.Delete(id)
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+type TaxRateService interface {
+	GetAllTaxRates() ([]models.TaxRate, error)
+	GetTaxRateByID(id int) (*models.TaxRate, error)
+	CreateTaxRate(rate models.TaxRate) (models.TaxRate, error)
+	UpdateTaxRate(id int, rate models.TaxRate) (*models.TaxRate, error)
+	DeleteTaxRate(id int) error
+}
+
+type taxRateService struct {
+	repository repositories.TaxRateRepository
+}
+
+func NewTaxRateService(repo repositories.TaxRateRepository) TaxRateService {
+	return &taxRateService{repository: repo}
+}
+
+func (s *taxRateService) GetAllTaxRates() ([]models.TaxRate, error) {
+	return s.repository.GetAll()
+}
+
+func (s *taxRateService) GetTaxRateByID(id int) (*models.TaxRate, error) {
+	return s.repository.GetByID(id)
+}
+
+func (s *taxRateService) CreateTaxRate(rate models.TaxRate) (models.TaxRate, error) {
+	return s.repository.Create(rate)
+}
+
+func (s *taxRateService) UpdateTaxRate(id int, rate models.TaxRate) (*models.TaxRate, error) {
+	return s.repository.Update(id, rate)
+}
+
+func (s *taxRateService) DeleteTaxRate(id int) error {
+	return s.repository.Delete(id)
+}
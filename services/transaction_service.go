@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"log"
+	"slices"
+	"time"
+)
+
+type TransactionService interface {
+	Checkout(userID int, req models.CheckoutRequest) (*models.Transaction, error)
+	Pay(userID, id int, req models.PayRequest) (*models.Transaction, error)
+	Cancel(id int) (*models.Transaction, error)
+	GetTransactionByID(id int) (*models.Transaction, error)
+}
+
+// PricingConfig holds the store-wide pricing settings applied at checkout.
+type PricingConfig struct {
+	// ServiceChargeRate is in basis points, so 500 is 5%. Zero disables
+	// the service charge.
+	ServiceChargeRate int
+	// Rounding is how percentage discounts, taxes and the service charge
+	// are rounded to whole minor units, and how cash payments are rounded.
+	Rounding models.RoundingMode
+	// CashRounding rounds the balance a customer settles in cash to a
+	// multiple of this amount, e.g. 100 or 500. Totals and non-cash
+	// payments are not rounded. Zero disables it.
+	CashRounding models.Money
+}
+
+type transactionService struct {
+	repository repositories.TransactionRepository
+	promotions repositories.PromotionRepository
+	taxRates   repositories.TaxRateRepository
+	providers  PaymentProviders
+	stock      StockWatcher
+	config     PricingConfig
+}
+
+// NewTransactionService returns the sales service. stock, which may be nil,
+// is told about the products of every sale.
+func NewTransactionService(repo repositories.TransactionRepository, promotions repositories.PromotionRepository,
+	taxRates repositories.TaxRateRepository, providers PaymentProviders, stock StockWatcher, config PricingConfig) TransactionService {
+	return &transactionService{repository: repo, promotions: promotions, taxRates: taxRates, providers: providers,
+		stock: stock, config: config}
+}
+
+// Checkout sells a cart as part of the user's open shift.
+func (s *transactionService) Checkout(userID int, req models.CheckoutRequest) (*models.Transaction, error) {
+	// Merge duplicate cart lines so stock is validated against the total
+	// quantity requested per product or variant.
+	type lineKey struct{ productID, variantID int }
+	var items []models.CheckoutItem
+	index := make(map[lineKey]int)
+	for _, item := range req.Items {
+		key := lineKey{productID: item.ProductID}
+		if item.VariantID != nil {
+			key.variantID = *item.VariantID
+		}
+		if i, ok := index[key]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		index[key] = len(items)
+		items = append(items, item)
+	}
+
+	promotions, err := s.checkoutPromotions(req.PromoCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryPaths := make(map[int][]int)
+	targetsCategory := slices.ContainsFunc(promotions, func(p models.Promotion) bool {
+		return p.TargetType == models.PromotionTargetCategory
+	})
+	if targetsCategory {
+		for _, item := range items {
+			if _, ok := categoryPaths[item.ProductID]; ok {
+				continue
+			}
+			path, err := s.promotions.GetCategoryPath(item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			categoryPaths[item.ProductID] = path
+		}
+	}
+
+	taxRates := make(map[int]*models.TaxRate)
+	for _, item := range items {
+		if _, ok := taxRates[item.ProductID]; ok {
+			continue
+		}
+		rate, err := s.taxRates.GetForProduct(item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		taxRates[item.ProductID] = rate
+	}
+
+	var settle repositories.SettleFunc
+	var charged []models.Payment
+	if len(req.Payments) > 0 {
+		settle = s.settle(req.Payments, &charged)
+	}
+	t, err := s.repository.Create(userID, items, func(t *models.Transaction) error {
+		applyPromotions(t, promotions, categoryPaths, s.config.Rounding)
+		applyTaxes(t, taxRates, s.config.ServiceChargeRate, s.config.Rounding)
+		return nil
+	}, settle)
+	if err != nil {
+		s.void(charged)
+		return nil, err
+	}
+
+	if s.stock != nil {
+		var productIDs []int
+		for _, item := range items {
+			if !slices.Contains(productIDs, item.ProductID) {
+				productIDs = append(productIDs, item.ProductID)
+			}
+		}
+		s.stock.StockSold(t.ID, productIDs)
+	}
+	return t, nil
+}
+
+// Pay takes payment for a transaction that was checked out unpaid, into the
+// user's open shift. It returns nil, nil if the transaction doesn't exist.
+func (s *transactionService) Pay(userID, id int, req models.PayRequest) (*models.Transaction, error) {
+	var charged []models.Payment
+	t, err := s.repository.Pay(userID, id, s.settle(req.Payments, &charged))
+	if err != nil {
+		s.void(charged)
+		return nil, err
+	}
+	return t, nil
+}
+
+// Cancel calls off an unpaid transaction and puts its stock back. It
+// returns nil, nil if the transaction doesn't exist.
+func (s *transactionService) Cancel(id int) (*models.Transaction, error) {
+	return s.repository.Cancel(id)
+}
+
+// settle returns a SettleFunc that checks the tenders cover the total, cash
+// rounding the balance if cash settles it, and charges each through the
+// provider for its method. Charges that succeed are
+// appended to charged so they can be voided if the sale is not stored.
+func (s *transactionService) settle(tenders []models.PaymentRequest, charged *[]models.Payment) repositories.SettleFunc {
+	return func(t *models.Transaction) error {
+		for _, tender := range tenders {
+			if s.providers[tender.Method] == nil {
+				return fmt.Errorf("%w: %s", models.ErrPaymentMethodNotSupported, tender.Method)
+			}
+		}
+		change, rounding, err := tenderChange(t.TotalAmount, tenders, s.config.CashRounding, s.config.Rounding)
+		if err != nil {
+			return err
+		}
+
+		for _, tender := range tenders {
+			payment := models.Payment{Method: tender.Method, Amount: tender.Amount, Reference: tender.Reference, Status: models.PaymentStatusPaid}
+			reference, err := s.providers[tender.Method].Charge(payment)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %v", models.ErrPaymentDeclined, tender.Method, err)
+			}
+			payment.Reference = reference
+			*charged = append(*charged, payment)
+			t.Payments = append(t.Payments, payment)
+		}
+		t.Change = change
+		t.Rounding = rounding
+		t.PaymentStatus = models.PaymentStatusPaid
+		return nil
+	}
+}
+
+// void cancels charges made for a sale that failed. A charge that cannot be
+// voided is logged for the cashier to reverse by hand.
+func (s *transactionService) void(charged []models.Payment) {
+	for _, payment := range charged {
+		if err := s.providers[payment.Method].Void(payment); err != nil {
+			log.Printf("failed to void %s payment %q of %s: %v", payment.Method, payment.Reference, payment.Amount, err)
+		}
+	}
+}
+
+// checkoutPromotions returns the promotions that apply to a checkout now:
+// the automatic ones plus the vouchers whose codes were given. A code that
+// doesn't name a currently valid voucher is an error.
+func (s *transactionService) checkoutPromotions(codes []string) ([]models.Promotion, error) {
+	applicable, err := s.promotions.GetApplicable(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	redeemed := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		redeemed[code] = false
+	}
+
+	var promotions []models.Promotion
+	for _, p := range applicable {
+		if p.Code != "" {
+			if _, ok := redeemed[p.Code]; !ok {
+				continue
+			}
+			redeemed[p.Code] = true
+		}
+		promotions = append(promotions, p)
+	}
+
+	for _, code := range codes {
+		if !redeemed[code] {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidPromoCode, code)
+		}
+	}
+	return promotions, nil
+}
+
+func (s *transactionService) GetTransactionByID(id int) (*models.Transaction, error) {
+	return s.repository.GetByID(id)
+}
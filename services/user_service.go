@@ -0,0 +1,81 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+	"log"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserService interface {
+	GetAllUsers(page, pageSize int) ([]models.User, *utils.PaginationMeta, error)
+	CreateUser(user models.User) (models.User, error)
+	EnsureInitialUser(user models.User) error
+}
+
+type userService struct {
+	repository repositories.UserRepository
+}
+
+func NewUserService(repo repositories.UserRepository) UserService {
+	return &userService{repository: repo}
+}
+
+func (s *userService) GetAllUsers(page, pageSize int) ([]models.User, *utils.PaginationMeta, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	users, total, err := s.repository.GetAll(pageSize, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPage := 0
+	if pageSize > 0 {
+		totalPage = (total + pageSize - 1) / pageSize
+	}
+
+	meta := &utils.PaginationMeta{
+		Page:      page,
+		Total:     total,
+		TotalPage: totalPage,
+	}
+
+	return users, meta, nil
+}
+
+func (s *userService) CreateUser(user models.User) (models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.PasswordHash = string(hash)
+	user.Password = ""
+
+	return s.repository.Create(user)
+}
+
+// EnsureInitialUser creates the given user when the users table is empty so
+// a fresh installation has an account to log in with.
+func (s *userService) EnsureInitialUser(user models.User) error {
+	total, err := s.repository.Count()
+	if err != nil {
+		return err
+	}
+	if total > 0 {
+		return nil
+	}
+
+	if _, err := s.CreateUser(user); err != nil {
+		return err
+	}
+	log.Printf("Created initial user %q", user.Username)
+	return nil
+}
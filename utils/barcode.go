@@ -0,0 +1,41 @@
+package utils
+
+import "errors"
+
+var (
+	ErrBarcodeFormat     = errors.New("barcode must be 8 (EAN-8), 12 (UPC-A) or 13 (EAN-13) digits")
+	ErrBarcodeCheckDigit = errors.New("barcode check digit is invalid")
+)
+
+// ValidateBarcode checks that code is an EAN-8, UPC-A or EAN-13 barcode with
+// a correct GS1 mod-10 check digit.
+func ValidateBarcode(code string) error {
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return ErrBarcodeFormat
+	}
+
+	digits := make([]int, len(code))
+	for i, c := range code {
+		if c < '0' || c > '9' {
+			return ErrBarcodeFormat
+		}
+		digits[i] = int(c - '0')
+	}
+
+	// Weights alternate 3, 1, 3, ... starting from the digit left of the
+	// check digit, whatever the barcode length.
+	sum := 0
+	for i := len(digits) - 2; i >= 0; i-- {
+		if (len(digits)-2-i)%2 == 0 {
+			sum += digits[i] * 3
+		} else {
+			sum += digits[i]
+		}
+	}
+	if (10-sum%10)%10 != digits[len(digits)-1] {
+		return ErrBarcodeCheckDigit
+	}
+	return nil
+}
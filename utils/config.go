@@ -1,12 +1,48 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port     string `mapstructure:"PORT"`
-	DBSource string `mapstructure:"DB_SOURCE"`
+	Port            string        `mapstructure:"PORT"`
+	DBSource        string        `mapstructure:"DB_SOURCE"`
+	AutoMigrate     bool          `mapstructure:"AUTO_MIGRATE"`
+	JWTSecret       string        `mapstructure:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `mapstructure:"REFRESH_TOKEN_TTL"`
+	AdminUsername   string        `mapstructure:"ADMIN_USERNAME"`
+	AdminPassword   string        `mapstructure:"ADMIN_PASSWORD"`
+	// ServiceChargeRate is in basis points, so 500 is 5%.
+	ServiceChargeRate int `mapstructure:"SERVICE_CHARGE_RATE"`
+	// RoundingMode is half_up, half_even or down.
+	RoundingMode string `mapstructure:"ROUNDING_MODE"`
+	// CashRounding rounds what is paid in cash to a multiple of this amount,
+	// e.g. 100.
+	CashRounding int64 `mapstructure:"CASH_ROUNDING"`
+	// QRISGateway is the QRIS payment provider: "mock", or empty to
+	// disable QRIS.
+	QRISGateway      string        `mapstructure:"QRIS_GATEWAY"`
+	QRISSecret       string        `mapstructure:"QRIS_SECRET"`
+	QRISExpiry       time.Duration `mapstructure:"QRIS_EXPIRY"`
+	QRISMerchantName string        `mapstructure:"QRIS_MERCHANT_NAME"`
+	QRISMerchantCity string        `mapstructure:"QRIS_MERCHANT_CITY"`
+	// StoreTimezone is the IANA timezone reports and receipts use, e.g.
+	// Asia/Jakarta.
+	StoreTimezone string `mapstructure:"STORE_TIMEZONE"`
+	// Store details printed on receipts.
+	StoreName     string `mapstructure:"STORE_NAME"`
+	StoreAddress  string `mapstructure:"STORE_ADDRESS"`
+	StorePhone    string `mapstructure:"STORE_PHONE"`
+	ReceiptFooter string `mapstructure:"RECEIPT_FOOTER"`
+	// ReceiptPaperWidth is the receipt roll width in mm, 58 or 80.
+	ReceiptPaperWidth int `mapstructure:"RECEIPT_PAPER_WIDTH"`
+	// LowStockNotifier is where low-stock alerts go: "log" or "webhook",
+	// which POSTs them to LowStockWebhookURL.
+	LowStockNotifier   string `mapstructure:"LOW_STOCK_NOTIFIER"`
+	LowStockWebhookURL string `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -14,6 +50,31 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
 
+	// Defaults also register the keys so they can be set from the
+	// environment alone.
+	viper.SetDefault("AUTO_MIGRATE", true)
+	viper.SetDefault("JWT_SECRET", "")
+	viper.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	viper.SetDefault("REFRESH_TOKEN_TTL", "168h")
+	viper.SetDefault("ADMIN_USERNAME", "")
+	viper.SetDefault("ADMIN_PASSWORD", "")
+	viper.SetDefault("SERVICE_CHARGE_RATE", 0)
+	viper.SetDefault("ROUNDING_MODE", "half_up")
+	viper.SetDefault("CASH_ROUNDING", 0)
+	viper.SetDefault("QRIS_GATEWAY", "")
+	viper.SetDefault("QRIS_SECRET", "")
+	viper.SetDefault("QRIS_EXPIRY", "15m")
+	viper.SetDefault("QRIS_MERCHANT_NAME", "KASIR API")
+	viper.SetDefault("QRIS_MERCHANT_CITY", "JAKARTA")
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("STORE_NAME", "Kasir")
+	viper.SetDefault("STORE_ADDRESS", "")
+	viper.SetDefault("STORE_PHONE", "")
+	viper.SetDefault("RECEIPT_FOOTER", "Thank you for shopping")
+	viper.SetDefault("RECEIPT_PAPER_WIDTH", 58)
+	viper.SetDefault("LOW_STOCK_NOTIFIER", "log")
+	viper.SetDefault("LOW_STOCK_WEBHOOK_URL", "")
+
 	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
@@ -66,6 +66,19 @@ func ResponseError(w http.ResponseWriter, code int, message string) {
 	RespondJSON(w, code, response)
 }
 
+// ResponseErrorWithData is ResponseError with details the client can act
+// on, such as what blocks a delete.
+func ResponseErrorWithData(w http.ResponseWriter, code int, message string, data interface{}) {
+	response := APIResponse{
+		Code:    code,
+		Status:  "error",
+		Message: message,
+		Data:    data,
+		Meta:    nil,
+	}
+	RespondJSON(w, code, response)
+}
+
 func ParseIDFromRequest(r *http.Request, w http.ResponseWriter) (int, bool) {
 	idStr := r.PathValue("id")
 	id, err := strconv.Atoi(idStr)